@@ -9,82 +9,144 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
-	"google.golang.org/api/docs/v1"
 )
 
-const (
-	scope           = docs.DriveScope         // needed for editing GDrive files
-	stateToken      = "state-token"           // used for requesting a new token
-	credentialsPath = "auth/credentials.json" // client secret
-	tokenPath       = "auth/token.json"       // token path, needs to change if scope changes
-)
+// The token cached on disk, along with the scopes it was granted for,
+// so that a scope change invalidates the cached token.
+type cachedToken struct {
+	*oauth2.Token
+	Scopes []string `json:"scopes"`
+}
 
-// Authorizes the client with an API token.
-func authorizeClient(config *oauth2.Config) (*http.Client, error) {
-	token, err := checkForToken()
+// A token source that caches every new (e.g. refreshed) token on disk.
+type cachingTokenSource struct {
+	mu     sync.Mutex
+	base   oauth2.TokenSource
+	path   string   // token path
+	scopes []string // scopes the token is granted for
+	last   string   // last cached access token
+}
+
+// Token gets a token from the underlying token source,
+// caching it if it has changed since it was last cached.
+func (s *cachingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.base.Token()
 	if err != nil {
-		log.Println("Unable to locate local token, attempting to get token from web.")
-		token, err = requestNewToken(config)
-		if err != nil {
-			return nil, err
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token.AccessToken != s.last {
+		if err := cacheToken(s.path, token, s.scopes); err != nil {
+			log.Printf("Failed to write token to file: %v\n", err)
+		} else {
+			s.last = token.AccessToken
 		}
-		cacheToken(token)
 	}
-	return config.Client(context.Background(), token), nil
+	return token, nil
 }
 
-// Request a new token from the Docs API.
-func requestNewToken(config *oauth2.Config) (*oauth2.Token, error) {
-	// get authorization code
-	log.Printf("Enter auth code from: \n%v\n", config.AuthCodeURL(stateToken, oauth2.AccessTypeOffline))
-	var auth string
-	_, err := fmt.Scan(&auth)
+// Authorizes the client with an API token.
+func authorizeClient(config *oauth2.Config, tokenPath string) (*http.Client, error) {
+	token, err := checkForToken(tokenPath, config.Scopes)
 	if err != nil {
-		return nil, errors.New("Failed to scan auth code: " + err.Error())
+		log.Printf("Unable to use local token (%v), attempting to get token from web.\n", err)
+		token, err = requestNewToken(config)
+		if err != nil {
+			return nil, err
+		}
+		if err = cacheToken(tokenPath, token, config.Scopes); err != nil {
+			log.Printf("Failed to write token to file: %v\n", err)
+		}
 	}
 
-	// get new token using auth code, passing empty context (same as TODO())
-	token, err := config.Exchange(oauth2.NoContext, auth)
-	if err != nil {
-		return nil, errors.New("Failed to get token: " + err.Error())
+	// persist any refreshed tokens
+	ctx := context.Background()
+	source := &cachingTokenSource{
+		base:   config.TokenSource(ctx, token),
+		path:   tokenPath,
+		scopes: config.Scopes,
+		last:   token.AccessToken,
 	}
-	return token, nil
+	return oauth2.NewClient(ctx, source), nil
 }
 
-// Checks if the client already has a local token.
-func checkForToken() (*oauth2.Token, error) {
+// Checks if the client already has a local token
+// that was granted for the same scopes.
+func checkForToken(tokenPath string, scopes []string) (*oauth2.Token, error) {
 	// open file for reading
 	file, err := os.Open(tokenPath)
-	defer file.Close()
 	if err != nil {
 		return nil, err
 	}
-	token := &oauth2.Token{}
+	defer file.Close()
 
-	// parse token json into Token
-	err = json.NewDecoder(file).Decode(token)
-	return token, err
+	// parse token json into cachedToken
+	var cached cachedToken
+	if err = json.NewDecoder(file).Decode(&cached); err != nil {
+		return nil, err
+	}
+	if cached.Token == nil {
+		return nil, errors.New("token missing")
+	}
+	if !sameScopes(cached.Scopes, scopes) {
+		return nil, fmt.Errorf("token scopes changed from `%s`", strings.Join(cached.Scopes, " "))
+	}
+	return cached.Token, nil
 }
 
-// Cache the new token.
-func cacheToken(token *oauth2.Token) {
-	// open file for writing, allow it to be read/written to, create if doesn't exist, truncate it to length 0
-	file, err := os.OpenFile(tokenPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
-	defer file.Close()
+// Checks if two sets of scopes are equal, ignoring order.
+func sameScopes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string(nil), a...), append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Cache the token atomically, by writing it to a temporary
+// file in the same directory and renaming it over the token path.
+func cacheToken(tokenPath string, token *oauth2.Token, scopes []string) error {
+	dir := filepath.Dir(tokenPath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	// temporary file is only readable/writable by the owner
+	file, err := ioutil.TempFile(dir, filepath.Base(tokenPath)+".*.tmp")
 	if err != nil {
-		log.Printf("\nFailed to write token to file: %v", err)
-		return
+		return err
 	}
+	defer os.Remove(file.Name()) // no-op once renamed
 
 	// encode the token into json
-	json.NewEncoder(file).Encode(token)
+	if err = json.NewEncoder(file).Encode(cachedToken{token, scopes}); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), tokenPath)
 }
 
-// GetAuthorizedClient gets the authorized (OAuth2) http Client
-func GetAuthorizedClient() (*http.Client, error) {
+// Gets the authorized (OAuth2) http Client using the installed-app flow.
+func getInstalledAppClient(credentialsPath, tokenPath string, scopes []string) (*http.Client, error) {
 	// read client secret
 	bytes, err := ioutil.ReadFile(credentialsPath)
 	if err != nil {
@@ -92,11 +154,31 @@ func GetAuthorizedClient() (*http.Client, error) {
 	}
 
 	// initialize config for client authorization
-	config, err := google.ConfigFromJSON(bytes, scope)
+	config, err := google.ConfigFromJSON(bytes, scopes...)
 	if err != nil {
 		return nil, errors.New("Failed to parse config: " + err.Error())
 	}
 
 	// authorize client (OAuth2)
-	return authorizeClient(config)
+	return authorizeClient(config, tokenPath)
+}
+
+// GetAuthorizedClient gets the authorized (OAuth2) http Client
+// for a particular authorization mode.
+// The client is only granted the scopes needed by the enabled features.
+func GetAuthorizedClient(opts *Options) (*http.Client, error) {
+	scopes := getScopes(opts.Features)
+	switch opts.Mode {
+	case InstalledMode:
+		if opts.Subject != "" {
+			return nil, fmt.Errorf("subject impersonation is not supported in `%s` mode", opts.Mode)
+		}
+		return getInstalledAppClient(opts.CredentialsPath, opts.TokenPath, scopes)
+	case ServiceAccountMode:
+		return getServiceAccountClient(opts.KeyPath, opts.Subject, scopes)
+	case DefaultCredentialsMode:
+		return getDefaultCredentialsClient(opts.Subject, scopes)
+	default:
+		return nil, fmt.Errorf("unknown auth mode: `%s`", opts.Mode)
+	}
 }
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// A token source that returns its tokens in order.
+type sequenceTokenSource struct {
+	tokens []*oauth2.Token
+}
+
+func (s *sequenceTokenSource) Token() (*oauth2.Token, error) {
+	token := s.tokens[0]
+	if len(s.tokens) > 1 {
+		s.tokens = s.tokens[1:]
+	}
+	return token, nil
+}
+
+func TestCacheToken(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	// the directory of the token is created
+	path := filepath.Join(dir, "config", "token.json")
+	scopes := []string{"a", "b"}
+	if err := cacheToken(path, &oauth2.Token{AccessToken: "access", RefreshToken: "refresh"}, scopes); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := checkForToken(path, []string{"b", "a"})
+	if err != nil {
+		t.Fatalf("checkForToken() error = %v", err)
+	}
+	if token.AccessToken != "access" || token.RefreshToken != "refresh" {
+		t.Errorf("checkForToken() = %+v, want the cached token", token)
+	}
+
+	// the temporary file is renamed over the token
+	files, err := ioutil.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("token dir has %d files, want 1", len(files))
+	}
+}
+
+func TestCheckForTokenScopeChange(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token.json")
+	if err := cacheToken(path, &oauth2.Token{AccessToken: "access"}, []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checkForToken(path, []string{"a", "b"}); err == nil {
+		t.Error("checkForToken() with changed scopes succeeded, want an error")
+	}
+}
+
+func TestCheckForTokenMissing(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token.json")
+	if _, err := checkForToken(path, nil); err == nil {
+		t.Error("checkForToken() without a token file succeeded, want an error")
+	}
+	if err := ioutil.WriteFile(path, []byte(`{"scopes": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checkForToken(path, nil); err == nil {
+		t.Error("checkForToken() without a token succeeded, want an error")
+	}
+}
+
+func TestCachingTokenSource(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token.json")
+	s := &cachingTokenSource{
+		base: &sequenceTokenSource{[]*oauth2.Token{
+			{AccessToken: "first"},
+			{AccessToken: "refreshed"},
+		}},
+		path:   path,
+		scopes: []string{"a"},
+		last:   "first",
+	}
+
+	// an unchanged token is not cached
+	if _, err := s.Token(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("unchanged token was cached (stat error = %v)", err)
+	}
+
+	// a refreshed token is cached
+	if _, err := s.Token(); err != nil {
+		t.Fatal(err)
+	}
+	token, err := checkForToken(path, []string{"a"})
+	if err != nil {
+		t.Fatalf("checkForToken() error = %v", err)
+	}
+	if token.AccessToken != "refreshed" {
+		t.Errorf("cached access token = %q, want %q", token.AccessToken, "refreshed")
+	}
+}
+
+func TestSameScopes(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want bool
+	}{
+		{nil, nil, true},
+		{[]string{"a", "b"}, []string{"b", "a"}, true},
+		{[]string{"a"}, []string{"a", "b"}, false},
+		{[]string{"a", "a"}, []string{"a", "b"}, false},
+	}
+	for _, tt := range tests {
+		if got := sameScopes(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameScopes(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	loopbackHost    = "127.0.0.1"     // host of the local redirect listener
+	loopbackTimeout = 5 * time.Minute // how long to wait for the user to authorize
+	pkceMethod      = "S256"          // PKCE code challenge method
+)
+
+// The result of the OAuth redirect to the local listener.
+type loopbackResult struct {
+	code string
+	err  error
+}
+
+// Gets a random URL-safe string from n random bytes.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Gets the PKCE S256 code challenge for a code verifier.
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Request a new token from the Docs API using the loopback redirect flow.
+// A local listener on an ephemeral port receives the authorization code,
+// which is exchanged for a token using PKCE.
+func requestNewToken(config *oauth2.Config) (*oauth2.Token, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(loopbackHost, "0"))
+	if err != nil {
+		return nil, errors.New("Failed to start loopback listener: " + err.Error())
+	}
+	defer listener.Close()
+
+	// copy config so the redirect URI only applies to this flow
+	c := *config
+	c.RedirectURL = fmt.Sprintf("http://%s", listener.Addr().String())
+
+	state, err := randomString(32)
+	if err != nil {
+		return nil, errors.New("Failed to generate state: " + err.Error())
+	}
+	verifier, err := randomString(64)
+	if err != nil {
+		return nil, errors.New("Failed to generate code verifier: " + err.Error())
+	}
+
+	results := make(chan loopbackResult, 1)
+	server := &http.Server{Handler: loopbackHandler(state, results)}
+	go server.Serve(listener)
+	defer server.Close()
+
+	log.Printf("Authorize the bot from: \n%v\n", c.AuthCodeURL(state,
+		oauth2.AccessTypeOffline,
+		oauth2.SetAuthURLParam("code_challenge", codeChallenge(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", pkceMethod),
+	))
+
+	var res loopbackResult
+	select {
+	case res = <-results:
+	case <-time.After(loopbackTimeout):
+		return nil, errors.New("Timed out waiting for authorization")
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	// get new token using auth code and code verifier
+	token, err := c.Exchange(context.Background(), res.code, oauth2.SetAuthURLParam("code_verifier", verifier))
+	if err != nil {
+		return nil, errors.New("Failed to get token: " + err.Error())
+	}
+	return token, nil
+}
+
+// Gets the handler for the OAuth redirect, which validates the state
+// and sends the authorization code (or an error) to the results channel.
+// Only the first redirect is reported.
+func loopbackHandler(state string, results chan<- loopbackResult) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// ignore anything other than the redirect (e.g. favicon requests)
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		q := r.URL.Query()
+		var res loopbackResult
+		switch {
+		case q.Get("state") != state:
+			res.err = errors.New("Authorization state mismatch")
+		case q.Get("error") != "":
+			res.err = fmt.Errorf("Authorization denied: %s", q.Get("error"))
+		case q.Get("code") == "":
+			res.err = errors.New("Authorization code missing")
+		default:
+			res.code = q.Get("code")
+		}
+
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Authorization complete, you may close this window.")
+		}
+
+		select {
+		case results <- res:
+		default:
+		}
+	})
+}
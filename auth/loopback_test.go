@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCodeChallenge(t *testing.T) {
+	// the example of RFC 7636, appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	if got := codeChallenge(verifier); got != want {
+		t.Errorf("codeChallenge(%q) = %q, want %q", verifier, got, want)
+	}
+}
+
+func TestRandomString(t *testing.T) {
+	a, err := randomString(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := randomString(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 32 bytes are 43 unpadded base64 characters
+	if len(a) != 43 {
+		t.Errorf("len(randomString(32)) = %d, want 43", len(a))
+	}
+	if a == b {
+		t.Errorf("randomString(32) returned %q twice", a)
+	}
+}
+
+func TestLoopbackHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		status int
+		code   string
+		err    bool
+	}{
+		{"code", "/?state=s&code=c", http.StatusOK, "c", false},
+		{"state mismatch", "/?state=other&code=c", http.StatusBadRequest, "", true},
+		{"missing state", "/?code=c", http.StatusBadRequest, "", true},
+		{"denied", "/?state=s&error=access_denied", http.StatusBadRequest, "", true},
+		{"missing code", "/?state=s", http.StatusBadRequest, "", true},
+	}
+	for _, tt := range tests {
+		results := make(chan loopbackResult, 1)
+		w := httptest.NewRecorder()
+		loopbackHandler("s", results).ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+		res := <-results
+		if res.code != tt.code || (res.err != nil) != tt.err {
+			t.Errorf("%s: result = %q, %v, want %q, error %v", tt.name, res.code, res.err, tt.code, tt.err)
+		}
+	}
+}
+
+func TestLoopbackHandlerOnlyReportsRedirect(t *testing.T) {
+	results := make(chan loopbackResult, 1)
+	h := loopbackHandler("s", results)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/favicon.ico", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("favicon status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	select {
+	case res := <-results:
+		t.Errorf("favicon reported a result: %+v", res)
+	default:
+	}
+
+	// only the first redirect is reported, and later ones do not block
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/?state=s&code=first", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/?state=s&code=second", nil))
+	if res := <-results; res.code != "first" {
+		t.Errorf("reported code = %q, want %q", res.code, "first")
+	}
+}
@@ -0,0 +1,30 @@
+package auth
+
+import "GDocs-Syntax-Highlighter/config"
+
+const (
+	// CredentialsEnv is the environment variable that
+	// overrides the default client secret path.
+	CredentialsEnv = "GDOCS_CREDENTIALS"
+
+	// TokenEnv is the environment variable that
+	// overrides the default token path.
+	TokenEnv = "GDOCS_TOKEN"
+
+	credentialsFile = "credentials.json" // client secret
+	tokenFile       = "token.json"       // cached token
+)
+
+// DefaultCredentialsPath gets the default client secret path,
+// which is $GDOCS_CREDENTIALS if set, otherwise inside the user
+// config dir (e.g. $XDG_CONFIG_HOME/gdocs-syntax-highlighter).
+func DefaultCredentialsPath() string {
+	return config.DefaultPath(CredentialsEnv, credentialsFile)
+}
+
+// DefaultTokenPath gets the default token path,
+// which is $GDOCS_TOKEN if set, otherwise inside the user
+// config dir (e.g. $XDG_CONFIG_HOME/gdocs-syntax-highlighter).
+func DefaultTokenPath() string {
+	return config.DefaultPath(TokenEnv, tokenFile)
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"GDocs-Syntax-Highlighter/config"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// Sets an environment variable, returning a func that restores it.
+func setEnv(t *testing.T, key, value string) func() {
+	old, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestDefaultPaths(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG_CONFIG_HOME is only used on Linux")
+	}
+	defer setEnv(t, "XDG_CONFIG_HOME", "/config")()
+	defer setEnv(t, CredentialsEnv, "")()
+	defer setEnv(t, TokenEnv, "")()
+
+	if got, want := DefaultCredentialsPath(), filepath.Join("/config", config.Dir, credentialsFile); got != want {
+		t.Errorf("DefaultCredentialsPath() = %q, want %q", got, want)
+	}
+	if got, want := DefaultTokenPath(), filepath.Join("/config", config.Dir, tokenFile); got != want {
+		t.Errorf("DefaultTokenPath() = %q, want %q", got, want)
+	}
+}
+
+func TestDefaultPathsFromEnv(t *testing.T) {
+	defer setEnv(t, CredentialsEnv, "/secrets/client.json")()
+	defer setEnv(t, TokenEnv, "/secrets/token.json")()
+
+	if got, want := DefaultCredentialsPath(), "/secrets/client.json"; got != want {
+		t.Errorf("DefaultCredentialsPath() = %q, want %q", got, want)
+	}
+	if got, want := DefaultTokenPath(), "/secrets/token.json"; got != want {
+		t.Errorf("DefaultTokenPath() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	// DocumentsFeature reads and highlights the Google Document.
+	// It is always enabled.
+	DocumentsFeature Feature = "documents"
+
+	// CommentsFeature posts format and run results as Google Drive comments.
+	// The `drive.file` scope covers the documents opened with this app.
+	CommentsFeature Feature = "comments"
+
+	// FoldersFeature discovers the documents in a Google Drive folder,
+	// which only needs to read the files of the folder.
+	FoldersFeature Feature = "folders"
+)
+
+var (
+	// the least-privilege scopes needed by each feature
+	featureScopes = map[Feature][]string{
+		DocumentsFeature: {docs.DocumentsScope},
+		CommentsFeature:  {docs.DriveFileScope},
+		FoldersFeature:   {docs.DriveReadonlyScope},
+	}
+)
+
+// Feature is a bot feature that needs particular OAuth scopes.
+type Feature string
+
+// Gets the scopes needed for the documents feature and
+// any other enabled features, without duplicates.
+func getScopes(features []Feature) []string {
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, f := range append([]Feature{DocumentsFeature}, features...) {
+		for _, s := range featureScopes[f] {
+			if !seen[s] {
+				seen[s] = true
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
+
+// ExplainForbidden wraps an API error with the scopes a feature needs
+// if the error is a 403, so that it is clear which scope is missing.
+// Other errors are returned as is.
+func ExplainForbidden(err error, feature Feature) error {
+	if e, ok := err.(*googleapi.Error); ok && e.Code == http.StatusForbidden {
+		return fmt.Errorf("%v (feature `%s` needs scope(s): %s)", err, feature, strings.Join(featureScopes[feature], ", "))
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/googleapi"
+)
+
+func TestGetScopes(t *testing.T) {
+	tests := []struct {
+		features []Feature
+		want     []string
+	}{
+		{nil, []string{docs.DocumentsScope}},
+		{[]Feature{DocumentsFeature}, []string{docs.DocumentsScope}},
+		{[]Feature{CommentsFeature}, []string{docs.DocumentsScope, docs.DriveFileScope}},
+		{[]Feature{CommentsFeature, CommentsFeature}, []string{docs.DocumentsScope, docs.DriveFileScope}},
+		{[]Feature{FoldersFeature}, []string{docs.DocumentsScope, docs.DriveReadonlyScope}},
+		{[]Feature{CommentsFeature, FoldersFeature}, []string{docs.DocumentsScope, docs.DriveFileScope, docs.DriveReadonlyScope}},
+	}
+	for _, tt := range tests {
+		if got := getScopes(tt.features); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("getScopes(%q) = %q, want %q", tt.features, got, tt.want)
+		}
+	}
+}
+
+func TestGetScopesWithoutFullDrive(t *testing.T) {
+	// the bot enables comments by default
+	for _, features := range [][]Feature{{CommentsFeature}, {CommentsFeature, FoldersFeature}} {
+		for _, s := range getScopes(features) {
+			if s == docs.DriveScope {
+				t.Errorf("getScopes(%q) requests the full drive scope", features)
+			}
+		}
+	}
+}
+
+func TestExplainForbidden(t *testing.T) {
+	forbidden := &googleapi.Error{Code: http.StatusForbidden, Message: "forbidden"}
+	err := ExplainForbidden(forbidden, CommentsFeature)
+	if !strings.Contains(err.Error(), "`comments`") || !strings.Contains(err.Error(), docs.DriveFileScope) {
+		t.Errorf("ExplainForbidden() = %v, want it to name the feature and its scope", err)
+	}
+
+	for _, other := range []error{
+		&googleapi.Error{Code: http.StatusNotFound},
+		errors.New("network"),
+		nil,
+	} {
+		if got := ExplainForbidden(other, CommentsFeature); got != other {
+			t.Errorf("ExplainForbidden(%v) = %v, want it unchanged", other, got)
+		}
+	}
+}
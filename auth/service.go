@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// InstalledMode is the interactive installed-app flow,
+	// where the user authorizes the bot from a browser.
+	InstalledMode Mode = "installed"
+
+	// ServiceAccountMode authorizes the bot using a service account JSON key.
+	ServiceAccountMode Mode = "service"
+
+	// DefaultCredentialsMode authorizes the bot using the
+	// Application Default Credentials (e.g. GOOGLE_APPLICATION_CREDENTIALS).
+	DefaultCredentialsMode Mode = "adc"
+
+	// DefaultMode is the default authorization mode.
+	DefaultMode = InstalledMode
+
+	// the `type` of a service account JSON key
+	serviceAccountKey = "service_account"
+)
+
+var (
+	modes = map[Mode]bool{
+		InstalledMode:          true,
+		ServiceAccountMode:     true,
+		DefaultCredentialsMode: true,
+	}
+)
+
+// Mode describes how the bot is authorized.
+type Mode string
+
+// Options describes how to authorize the client.
+type Options struct {
+	Mode            Mode      // authorization mode
+	CredentialsPath string    // path to the client secret, for InstalledMode
+	TokenPath       string    // path to the cached token, for InstalledMode
+	KeyPath         string    // path to the service account JSON key, for ServiceAccountMode
+	Subject         string    // optional user to impersonate with domain-wide delegation
+	Features        []Feature // enabled features, which determine the requested scopes
+}
+
+// GetMode attempts to get an authorization Mode
+// from a case insensitive string.
+func GetMode(mode string) (Mode, bool) {
+	m := Mode(strings.ToLower(mode))
+	return m, modes[m]
+}
+
+// Gets the http Client authorized by a service account JSON key,
+// impersonating the subject if it is non-empty.
+func getServiceAccountClient(keyPath, subject string, scopes []string) (*http.Client, error) {
+	if keyPath == "" {
+		return nil, errors.New("Service account key path must be set")
+	}
+
+	// read service account key
+	bytes, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, errors.New("Failed to read service account key: " + err.Error())
+	}
+	return getJWTClient(bytes, subject, scopes)
+}
+
+// Gets the http Client authorized by the Application Default Credentials.
+// Impersonating a subject requires the credentials to be a service account key,
+// since domain-wide delegation signs its own JWT.
+func getDefaultCredentialsClient(subject string, scopes []string) (*http.Client, error) {
+	creds, err := google.FindDefaultCredentials(context.Background(), scopes...)
+	if err != nil {
+		return nil, errors.New("Failed to find default credentials: " + err.Error())
+	}
+	if subject == "" {
+		return oauth2.NewClient(context.Background(), creds.TokenSource), nil
+	}
+	if !isServiceAccountKey(creds.JSON) {
+		return nil, errors.New("Subject impersonation requires the default credentials to be a service account key")
+	}
+	return getJWTClient(creds.JSON, subject, scopes)
+}
+
+// Gets the http Client for a service account JSON key.
+func getJWTClient(key []byte, subject string, scopes []string) (*http.Client, error) {
+	config, err := google.JWTConfigFromJSON(key, scopes...)
+	if err != nil {
+		return nil, errors.New("Failed to parse service account key: " + err.Error())
+	}
+	config.Subject = subject
+	return config.Client(context.Background()), nil
+}
+
+// Checks if the JSON credentials are a service account key.
+func isServiceAccountKey(key []byte) bool {
+	var f struct {
+		Type string `json:"type"`
+	}
+	return json.Unmarshal(key, &f) == nil && f.Type == serviceAccountKey
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testServiceAccountKey = `{
+	"type": "service_account",
+	"client_email": "bot@project.iam.gserviceaccount.com",
+	"private_key": "unused",
+	"token_uri": "https://oauth2.googleapis.com/token"
+}`
+
+func TestGetMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		want Mode
+		ok   bool
+	}{
+		{"installed", InstalledMode, true},
+		{"Service", ServiceAccountMode, true},
+		{"ADC", DefaultCredentialsMode, true},
+		{"oob", Mode("oob"), false},
+		{"", Mode(""), false},
+	}
+	for _, tt := range tests {
+		got, ok := GetMode(tt.mode)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("GetMode(%q) = %q, %v, want %q, %v", tt.mode, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestIsServiceAccountKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{testServiceAccountKey, true},
+		{`{"type": "authorized_user"}`, false},
+		{`{}`, false},
+		{`not json`, false},
+	}
+	for _, tt := range tests {
+		if got := isServiceAccountKey([]byte(tt.key)); got != tt.want {
+			t.Errorf("isServiceAccountKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestGetAuthorizedClientErrors(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"unknown mode", Options{Mode: "oob"}, "unknown auth mode"},
+		{"installed subject", Options{Mode: InstalledMode, Subject: "user@example.com"}, "subject impersonation is not supported"},
+		{"service without key", Options{Mode: ServiceAccountMode}, "key path must be set"},
+		{"service missing key", Options{Mode: ServiceAccountMode, KeyPath: filepath.Join(dir, "key.json")}, "Failed to read service account key"},
+	}
+	for _, tt := range tests {
+		_, err := GetAuthorizedClient(&tt.opts)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: GetAuthorizedClient() error = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestGetServiceAccountClient(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	keyPath := filepath.Join(dir, "key.json")
+	if err := ioutil.WriteFile(keyPath, []byte(testServiceAccountKey), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getServiceAccountClient(keyPath, "user@example.com", []string{"scope"}); err != nil {
+		t.Errorf("getServiceAccountClient() error = %v", err)
+	}
+
+	badPath := filepath.Join(dir, "bad.json")
+	if err := ioutil.WriteFile(badPath, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getServiceAccountClient(badPath, "", []string{"scope"}); err == nil {
+		t.Error("getServiceAccountClient() with an invalid key succeeded, want an error")
+	}
+}
+
+// Creates a temporary directory, which the test must remove.
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
@@ -2,13 +2,17 @@ package main
 
 import (
 	"GDocs-Syntax-Highlighter/auth"
+	"GDocs-Syntax-Highlighter/config"
 	"GDocs-Syntax-Highlighter/parser"
 	"GDocs-Syntax-Highlighter/request"
+	"GDocs-Syntax-Highlighter/runner"
+	"GDocs-Syntax-Highlighter/style"
 	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"google.golang.org/api/docs/v1"
@@ -16,8 +20,51 @@ import (
 	"google.golang.org/api/option"
 )
 
+// Posts a Google Drive comment on the document, where desc describes the comment
+// for logging, anchored to code if the anchor is not nil.
+// If comments are disabled (nil service), the comment is only logged.
+func postComment(text, desc string, anchor *request.Anchor, docID string, comments *drive.CommentsService) {
+	if comments == nil {
+		log.Printf("Comments disabled, %s:\n%s\n", desc, text)
+		return
+	}
+	if _, err := request.CreateComment(text, anchor, docID, comments).Do(); err != nil {
+		log.Printf("Failed to create comment for %s: %v\n", desc, auth.ExplainForbidden(err, auth.CommentsFeature))
+	}
+}
+
+// A comment to post after the document is updated, which is anchored
+// to the code of its diagnostics in the updated document, if any.
+type pendingComment struct {
+	text        string // text of the comment
+	desc        string // description of the comment for logging
+	diagnostics []runner.Diagnostic
+	anchor      *request.Anchor
+}
+
+// The problems found by linting, running or formatting
+// the code, which are highlighted until the code changes.
+type foundProblems struct {
+	code        string
+	diagnostics []runner.Diagnostic
+}
+
+// Formats diagnostics as one per line.
+func formatDiagnostics(diagnostics []runner.Diagnostic) string {
+	var b strings.Builder
+	for _, d := range diagnostics {
+		fmt.Fprintln(&b, d)
+	}
+	return b.String()
+}
+
 func start(docID string, update time.Duration, verbose bool, docsService *docs.Service, driveService *drive.Service) {
-	comments := drive.NewCommentsService(driveService)
+	var comments *drive.CommentsService
+	if driveService != nil {
+		comments = drive.NewCommentsService(driveService)
+	}
+
+	var problems *foundProblems
 
 	for {
 		if verbose {
@@ -25,13 +72,17 @@ func start(docID string, update time.Duration, verbose bool, docsService *docs.S
 		}
 		doc, err := docsService.Documents.Get(docID).Do()
 		if err != nil {
-			log.Fatalf("Failed to get doc: %v", err)
+			log.Fatalf("Failed to get doc: %v", auth.ExplainForbidden(err, auth.DocumentsFeature))
 		}
 
 		var docsReqs []*docs.Request
+		var pending []*pendingComment // comments to post after the update
 
 		// process each instance of code found in the Google Doc
 		instance := parser.GetCodeInstance(doc)
+		if verbose && instance.Detected {
+			log.Printf("Detected language: `%s`\n", instance.Lang.Name)
+		}
 
 		if *instance.Shortcuts {
 			// preprocess by replacing regex matches with specific strings
@@ -46,14 +97,19 @@ func start(docID string, update time.Duration, verbose bool, docsService *docs.S
 			// the code was formatted or attempted to be formatted
 			docsReqs = append(docsReqs, request.SetUnderline(false, instance.Format.GetRange()))
 
-			if instance.Lang.Format == nil {
-				panic(fmt.Sprintf("no format func defined for language: `%s`", instance.Lang.Name))
-			}
-			if formatted, err := instance.Lang.Format(instance.Code); err != nil {
+			if instance.Diff {
+				log.Println("Can not format a diff.")
+				pending = append(pending, &pendingComment{text: "Format Unsupported:\ncan not format a diff", desc: "unsupported format"})
+			} else if instance.Lang.Format == nil {
+				log.Printf("No format func defined for language: `%s`\n", instance.Lang.Name)
+				pending = append(pending, &pendingComment{text: fmt.Sprintf("Format Unsupported:\nno format func defined for language: `%s`", instance.Lang.Name), desc: "unsupported format"})
+			} else if formatted, err := instance.Lang.Format(instance.Code, instance.Options); err != nil {
 				log.Printf("Failed to format: %v\n", err)
-				if _, err = request.CreateComment(fmt.Sprintf("Format Failure:\n%v", err), docID, comments).Do(); err != nil {
-					log.Printf("Failed to create comment for format failure: %v\n", err)
+				diagnostics := runner.ParseDiagnostics(instance.Code, err.Error())
+				if len(diagnostics) > 0 {
+					problems = &foundProblems{instance.Code, diagnostics}
 				}
+				pending = append(pending, &pendingComment{text: fmt.Sprintf("Format Failure:\n%v", err), desc: "format failure", diagnostics: diagnostics})
 			} else {
 				log.Println("Formatted the program.")
 
@@ -76,28 +132,53 @@ func start(docID string, update time.Duration, verbose bool, docsService *docs.S
 			docsReqs = append(docsReqs, request.SetUnderline(false, instance.Run.GetRange()))
 
 			if instance.Lang.Run == nil {
-				panic(fmt.Sprintf("no run func defined for language: `%s`", instance.Lang.Name))
-			}
-			res, err := instance.Lang.Run(instance.Code)
-			if err != nil {
+				log.Printf("No run func defined for language: `%s`\n", instance.Lang.Name)
+				pending = append(pending, &pendingComment{text: fmt.Sprintf("Run Unsupported:\nno run func defined for language: `%s`", instance.Lang.Name), desc: "unsupported run"})
+			} else if res, err := instance.Lang.Run(instance.Code, instance.Options); err != nil {
 				log.Printf("Failed to run: %v\n", err)
-				if _, err = request.CreateComment(fmt.Sprintf("Run Internal Failure:\n%v", err), docID, comments).Do(); err != nil {
-					log.Printf("Failed to create comment for run internal failure: %v\n", err)
-				}
+				pending = append(pending, &pendingComment{text: fmt.Sprintf("Run Internal Failure:\n%v", err), desc: "run internal failure"})
 			} else {
 				log.Printf("Ran the program (status=%d).\n", res.Status)
 				if verbose {
 					log.Printf("Program errors: %s\n", res.Errors)
 					log.Printf("Program output: %s\n", res.Output)
 				}
-				if res.Errors == "" {
-					if _, err = request.CreateComment(fmt.Sprintf("Run Success (status=%d):\n%s", res.Status, res.Output), docID, comments).Do(); err != nil {
-						log.Printf("Failed to create comment for run success: %v\n", err)
-					}
+				if res.Errors == "" && res.Status == 0 {
+					pending = append(pending, &pendingComment{text: fmt.Sprintf("Run Success (status=%d):\n%s", res.Status, res.Output), desc: "run success"})
 				} else {
-					if _, err = request.CreateComment(fmt.Sprintf("Run Failure (status=%d):\n%s", res.Status, res.Errors), docID, comments).Do(); err != nil {
-						log.Printf("Failed to create comment for run failure: %v\n", err)
+					// highlight the compile errors, if any
+					diagnostics := runner.ParseDiagnostics(instance.Code, res.Errors)
+					if len(diagnostics) > 0 {
+						problems = &foundProblems{instance.Code, diagnostics}
+					}
+					errors := res.Errors
+					if errors == "" {
+						errors = res.Output // exited with a non-zero status without errors
 					}
+					pending = append(pending, &pendingComment{text: fmt.Sprintf("Run Failure (status=%d):\n%s", res.Status, errors), desc: "run failure", diagnostics: diagnostics})
+				}
+			}
+		}
+
+		// attempt to lint program
+		if instance.Lint.Underlined {
+			// un-underline the #lint directive to notify user that
+			// the code was linted or attempted to be linted
+			docsReqs = append(docsReqs, request.SetUnderline(false, instance.Lint.GetRange()))
+
+			if instance.Lang.Lint == nil {
+				log.Printf("No lint func defined for language: `%s`\n", instance.Lang.Name)
+				pending = append(pending, &pendingComment{text: fmt.Sprintf("Lint Unsupported:\nno lint func defined for language: `%s`", instance.Lang.Name), desc: "unsupported lint"})
+			} else if diagnostics, err := instance.Lang.Lint(instance.Code, instance.Options); err != nil {
+				log.Printf("Failed to lint: %v\n", err)
+				pending = append(pending, &pendingComment{text: fmt.Sprintf("Lint Internal Failure:\n%v", err), desc: "lint internal failure"})
+			} else {
+				log.Printf("Linted the program (problems=%d).\n", len(diagnostics))
+				problems = &foundProblems{instance.Code, diagnostics}
+				if len(diagnostics) == 0 {
+					pending = append(pending, &pendingComment{text: "Lint Success:\nno problems found", desc: "lint success"})
+				} else {
+					pending = append(pending, &pendingComment{text: fmt.Sprintf("Lint Problems (%d):\n%s", len(diagnostics), formatDiagnostics(diagnostics)), desc: "lint problems", diagnostics: diagnostics})
 				}
 			}
 		}
@@ -130,23 +211,65 @@ func start(docID string, update time.Duration, verbose bool, docsService *docs.S
 			))
 		}
 
+		// color the lines of a diff by their change
+		if instance.Diff {
+			docsReqs = append(docsReqs, instance.HighlightDiff(t)...)
+		}
+
+		// highlight the lines of the #highlight directive
+		docsReqs = append(docsReqs, instance.HighlightLines(t)...)
+
+		// update the line numbers, which are inserted after the code is highlighted
+		lineNumberReqs := instance.UpdateLineNumbers(t)
+
+		// anchor the comments to their code in the updated document,
+		// whose line numbers are updated and whose code is formatted
+		for _, c := range pending {
+			c.anchor = instance.GetAnchor(c.diagnostics)
+		}
+
+		// highlight the problems found by the last lint, run or format, until the code changes
+		if problems != nil && problems.code != instance.Code {
+			problems = nil
+		}
+		if problems != nil {
+			docsReqs = append(docsReqs, instance.HighlightDiagnostics(problems.diagnostics, t)...)
+		}
+
 		// remove ranges from instance.Code and add the requests to highlight them
 		docsReqs = append(docsReqs, instance.RemoveRanges(t)...)
 
 		// highlight code keywords using regexes
-		for _, k := range t.Keywords {
-			docsReqs = append(docsReqs, instance.Highlight(k.Regex, k.Color, "")...)
+		docsReqs = append(docsReqs, instance.HighlightKeywords(t)...)
+
+		// color brackets by their depth
+		if *instance.Brackets {
+			docsReqs = append(docsReqs, instance.HighlightBrackets(t)...)
 		}
 
+		docsReqs = append(docsReqs, lineNumberReqs...)
+
+		// report the detected language in the header
+		docsReqs = append(docsReqs, instance.ReportDetectedLanguage()...)
+
 		// update Google Document
 		if len(docsReqs) > 0 {
 			update := request.BatchUpdate(docsReqs)
 			_, err := docsService.Documents.BatchUpdate(docID, update).Do()
 			if err != nil {
-				log.Printf("Failed to update Google Doc: %v\n", err)
+				log.Printf("Failed to update Google Doc: %v\n", auth.ExplainForbidden(err, auth.DocumentsFeature))
+				// the anchors are not in the document that was not updated
+				for _, c := range pending {
+					c.anchor = nil
+				}
 			}
 		}
 
+		// post the comments after the update, so that their anchors are in the document
+		for _, c := range pending {
+			postComment(c.text, c.desc, c.anchor, docID, comments)
+		}
+
 		if verbose {
 			log.Println("Sleeping...")
 		}
@@ -160,9 +283,21 @@ func main() {
 	var docID string
 	var update int
 	var verbose bool
+	var enableComments bool
+	var authMode string
+	var authOpts auth.Options
 	flag.StringVar(&docID, "doc", "", "Set the Google Document ID.")
 	flag.IntVar(&update, "update", 1500, "Interval in milliseconds (>= 500) to update the Google Document.")
 	flag.BoolVar(&verbose, "v", false, "Verbose mode.")
+	flag.BoolVar(&enableComments, "comments", true, "Post format/run results as Google Drive comments (needs the drive.file scope).")
+	flag.StringVar(&runner.FixtureDir, "fixtures", config.DefaultPath(runner.FixtureDirEnv, runner.FixtureDir), "Set the directory of SQL fixture files (#fixture=<name> seeds from <name>.sql).")
+	flag.StringVar(&runner.SchemaDir, "schemas", config.DefaultPath(runner.SchemaDirEnv, runner.SchemaDir), "Set the directory of JSON Schema files (#schema=<name> validates with <name>.json).")
+	flag.StringVar(&style.LanguageDir, "languages", config.DefaultPath(style.LanguageDirEnv, style.LanguageDir), "Set the directory of language definition files (<name>.json) to register.")
+	flag.StringVar(&authMode, "auth", string(auth.DefaultMode), "Set the authorization mode (installed, service, adc).")
+	flag.StringVar(&authOpts.CredentialsPath, "credentials", auth.DefaultCredentialsPath(), "Set the client secret path (installed mode).")
+	flag.StringVar(&authOpts.TokenPath, "token", auth.DefaultTokenPath(), "Set the cached token path (installed mode).")
+	flag.StringVar(&authOpts.KeyPath, "key", "", "Set the service account JSON key path (service mode).")
+	flag.StringVar(&authOpts.Subject, "subject", "", "Set the user to impersonate with domain-wide delegation (service, adc modes).")
 	flag.Parse()
 
 	if docID == "" {
@@ -175,8 +310,27 @@ func main() {
 		os.Exit(1)
 	}
 
+	// register languages from definition files
+	langs, err := style.LoadLanguages(style.LanguageDir)
+	if err != nil {
+		log.Fatalf("Failed to load languages: %v", err)
+	}
+	for _, l := range langs {
+		log.Printf("Registered language: `%s`\n", l.Name)
+	}
+
+	mode, ok := auth.GetMode(authMode)
+	if !ok {
+		flag.Usage()
+		os.Exit(1)
+	}
+	authOpts.Mode = mode
+	if enableComments {
+		authOpts.Features = append(authOpts.Features, auth.CommentsFeature)
+	}
+
 	// get authorized client
-	client, err := auth.GetAuthorizedClient()
+	client, err := auth.GetAuthorizedClient(&authOpts)
 	if err != nil {
 		log.Fatalf("Failed to authorize client: %v", err)
 	}
@@ -187,10 +341,13 @@ func main() {
 		log.Fatalf("Failed to create Docs service: %v", err)
 	}
 
-	// create drive service
-	driveService, err := drive.NewService(context.Background(), option.WithHTTPClient(client))
-	if err != nil {
-		log.Fatalf("Failed to create Drive service: %v", err)
+	// create drive service, only needed for comments
+	var driveService *drive.Service
+	if enableComments {
+		driveService, err = drive.NewService(context.Background(), option.WithHTTPClient(client))
+		if err != nil {
+			log.Fatalf("Failed to create Drive service: %v", err)
+		}
 	}
 
 	// start checking document
@@ -0,0 +1,25 @@
+// Package config locates the files of the bot in the user config dir.
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Dir is the directory of the bot inside the user config dir.
+const Dir = "gdocs-syntax-highlighter"
+
+// DefaultPath gets a path from an environment variable, falling back
+// to a file (or directory) in the user config dir, so that the bot
+// does not depend on the directory it runs from.
+func DefaultPath(env, file string) string {
+	if v, ok := os.LookupEnv(env); ok && v != "" {
+		return v
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		// no home directory, so fall back to the working directory
+		return file
+	}
+	return filepath.Join(dir, Dir, file)
+}
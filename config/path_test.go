@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+const testEnv = "GDOCS_TEST_PATH"
+
+// Sets an environment variable, returning a func that restores it.
+func setEnv(t *testing.T, key, value string) func() {
+	old, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG_CONFIG_HOME is only used on Linux")
+	}
+	defer setEnv(t, "XDG_CONFIG_HOME", "/config")()
+	defer setEnv(t, testEnv, "")()
+
+	if got, want := DefaultPath(testEnv, "fixtures"), filepath.Join("/config", Dir, "fixtures"); got != want {
+		t.Errorf("DefaultPath() = %q, want %q", got, want)
+	}
+}
+
+func TestDefaultPathFromEnv(t *testing.T) {
+	defer setEnv(t, testEnv, "/data/fixtures")()
+
+	if got, want := DefaultPath(testEnv, "fixtures"), "/data/fixtures"; got != want {
+		t.Errorf("DefaultPath() = %q, want %q", got, want)
+	}
+}
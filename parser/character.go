@@ -65,7 +65,8 @@ func GetUtf16StringSize(s string) int64 {
 	return size
 }
 
-// MapToUTF16 maps the instance's utf8 non-empty Code string to utf16 rune indices + an offset.
+// MapToUTF16 maps the instance's utf8 non-empty Code string to utf16 rune indices + an offset,
+// skipping any line numbers before its lines.
 // Also sets the EndIndex in case it changed during any formatting.
 func (c *CodeInstance) MapToUTF16() {
 	if c.Code == "" {
@@ -76,7 +77,12 @@ func (c *CodeInstance) MapToUTF16() {
 	}
 
 	utf16Index := *c.StartIndex
+	var line int
 	for i, r := range c.Code {
+		if i == 0 || c.Code[i-1] == '\n' {
+			utf16Index += GetUtf16StringSize(c.getGutter(line))
+			line++
+		}
 		utf16Width := GetUtf16RuneSize(r)
 
 		// map zero-based utf8 -> utf16 + offset
@@ -0,0 +1,20 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestMapToUTF16(t *testing.T) {
+	startIndex := int64(5)
+	c := &CodeInstance{Code: "é😀c\n", StartIndex: &startIndex, toUTF16: make(map[int]int64)}
+	c.MapToUTF16()
+	want := map[int]int64{0: 5, 2: 6, 6: 8, 7: 9}
+	for utf8Index, utf16Index := range want {
+		if c.toUTF16[utf8Index] != utf16Index {
+			t.Errorf("toUTF16[%d] = %d, want %d", utf8Index, c.toUTF16[utf8Index], utf16Index)
+		}
+	}
+	if *c.EndIndex != 10 {
+		t.Errorf("EndIndex = %d, want 10", *c.EndIndex)
+	}
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"GDocs-Syntax-Highlighter/request"
+	"GDocs-Syntax-Highlighter/style"
+	"regexp"
+	"strings"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// A header line of a diff, such as a hunk header (`@@ -1,3 +1,4 @@`).
+	diffHeaderRegex = regexp.MustCompile("^(@@|diff |index |(new|deleted) file mode |(old|new) mode |similarity index |rename (from|to) |Binary files |\\\\ )")
+
+	// A file header line of a diff (`--- a/main.go` and `+++ b/main.go`),
+	// which is only a header outside of a hunk, where it can be a changed line.
+	diffFileHeaderRegex = regexp.MustCompile("^(---|\\+\\+\\+) ")
+
+	// The markers of the lines of a hunk.
+	diffAdded, diffRemoved, diffContext = "+", "-", " "
+)
+
+// Strips the diff markers (`+`, `-` or ` `) before the lines of the instance's Code,
+// and the header lines, keeping them so that the code can be mapped to the document.
+// It must be called after the line numbers are stripped.
+func (c *CodeInstance) stripDiffMarkers() {
+	var b strings.Builder
+	var hunk bool // whether the line is inside of a hunk
+	c.diffMarkers = nil
+	for _, line := range getLines(c.Code) {
+		var marker string
+		switch {
+		case diffHeaderRegex.MatchString(line):
+			// the header is the whole line besides its newline
+			marker = strings.TrimSuffix(line, "\n")
+			if strings.HasPrefix(line, "@@") {
+				hunk = true
+			} else if strings.HasPrefix(line, "diff ") {
+				hunk = false
+			}
+		case !hunk && diffFileHeaderRegex.MatchString(line):
+			marker = strings.TrimSuffix(line, "\n")
+		case strings.HasPrefix(line, diffAdded), strings.HasPrefix(line, diffRemoved), strings.HasPrefix(line, diffContext):
+			marker = line[:1]
+		}
+		c.diffMarkers = append(c.diffMarkers, marker)
+		_, err := b.WriteString(line[len(marker):])
+		check(err)
+	}
+	c.Code = b.String()
+}
+
+// HighlightDiff gets the requests to highlight the background of the lines added
+// and removed by the diff with the theme's diff colors, and to color its headers.
+// It must be called before any ranges are removed.
+func (c *CodeInstance) HighlightDiff(t *style.Theme) (reqs []*docs.Request) {
+	var lineStart int
+	for i, line := range getLines(c.Code) {
+		lineEnd := lineStart + len(line)
+		switch marker := getPrefix(c.diffMarkers, i); marker {
+		case "", diffContext:
+		case diffAdded:
+			reqs = append(reqs, request.UpdateBackgroundColor(t.DiffAdded, c.getUTF16Range(lineStart, lineEnd, "")))
+		case diffRemoved:
+			reqs = append(reqs, request.UpdateBackgroundColor(t.DiffRemoved, c.getUTF16Range(lineStart, lineEnd, "")))
+		default:
+			// the header is before the rest of its line (its newline)
+			utf16End := c.toUTF16[lineStart]
+			utf16Range := request.GetRange(utf16End-GetUtf16StringSize(marker), utf16End, "")
+			reqs = append(reqs, request.UpdateForegroundColor(t.DiffHeader, utf16Range))
+		}
+		lineStart = lineEnd
+	}
+	return
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"GDocs-Syntax-Highlighter/style"
+	"reflect"
+	"testing"
+)
+
+func TestStripDiffMarkers(t *testing.T) {
+	// a header is the whole line besides its newline
+	doc := "diff --git a/f b/f\n--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@ func f() {\n a\n-b\n+c\n--- d\n\\ No newline at end of file\nplain\n"
+	c := &CodeInstance{Code: doc}
+	c.stripDiffMarkers()
+	if want := "\n\n\n\na\nb\nc\n-- d\n\nplain\n"; c.Code != want {
+		t.Errorf("Code = %q, want %q", c.Code, want)
+	}
+	want := []string{
+		"diff --git a/f b/f", "--- a/f", "+++ b/f", "@@ -1,3 +1,3 @@ func f() {",
+		// a changed line in a hunk is not a file header
+		" ", "-", "+", "-",
+		"\\ No newline at end of file", "",
+	}
+	if !reflect.DeepEqual(c.diffMarkers, want) {
+		t.Errorf("diffMarkers = %q, want %q", c.diffMarkers, want)
+	}
+	// the stripped markers restore the document
+	if got := getDoc(c); got != doc {
+		t.Errorf("document = %q, want %q", got, doc)
+	}
+}
+
+func TestHighlightDiff(t *testing.T) {
+	// the code of the lines is highlighted with the language
+	doc := "1 │ @@ -1 +1 @@\n2 │ -return 1\n3 │ +return 2\n4 │  x\n"
+	c := newDocInstance(t, "go", doc)
+	c.Diff = true
+	c.stripDiffMarkers()
+	c.MapToUTF16()
+	th := c.GetTheme()
+	reqs := c.HighlightDiff(th)
+
+	runes := []rune(doc)
+	ranges, colors := getShadings(reqs)
+	var shaded []string
+	for _, r := range ranges {
+		shaded = append(shaded, string(runes[r[0]-1:r[1]-1]))
+	}
+	if want := []string{"return 1\n", "return 2\n"}; !reflect.DeepEqual(shaded, want) {
+		t.Errorf("shaded lines = %q, want %q", shaded, want)
+	}
+	if len(colors) == 2 && (colors[0] != th.DiffRemoved || colors[1] != th.DiffAdded) {
+		t.Errorf("shading colors = %s, %s, want %s, %s", colorName(colors[0]), colorName(colors[1]), colorName(th.DiffRemoved), colorName(th.DiffAdded))
+	}
+
+	var headers []string
+	for _, req := range reqs {
+		if u := req.UpdateTextStyle; u != nil && u.TextStyle.ForegroundColor.Color == th.DiffHeader {
+			headers = append(headers, string(runes[u.Range.StartIndex-1:u.Range.EndIndex-1]))
+		}
+	}
+	if want := []string{"@@ -1 +1 @@"}; !reflect.DeepEqual(headers, want) {
+		t.Errorf("colored headers = %q, want %q", headers, want)
+	}
+
+	checkColors(t, "go", "light", c.Code, []colored{
+		{"return", style.LightThemePink},
+		{"1", style.LightThemePaleGreen},
+		{"return", style.LightThemePink},
+	})
+}
+
+func TestDiffDirective(t *testing.T) {
+	c := &CodeInstance{Code: "+a\n"}
+	c.checkForDirectives("#DIFF", "", nil)
+	c.setDefaults()
+	if !c.Diff || c.Lang.Name == "Diff" {
+		t.Errorf("Diff = %v, language = %s, want a diff of the detected language", c.Diff, c.Lang.Name)
+	}
+	// the diff language is always a diff
+	d := newDocInstance(t, "diff", "+a\n")
+	if !d.Diff || d.Code != "a\n" {
+		t.Errorf("Diff = %v, Code = %q, want a diff", d.Diff, d.Code)
+	}
+}
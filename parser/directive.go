@@ -3,6 +3,7 @@ package parser
 import (
 	"GDocs-Syntax-Highlighter/request"
 	"GDocs-Syntax-Highlighter/style"
+	"fmt"
 	"log"
 	"regexp"
 	"strconv"
@@ -23,6 +24,24 @@ var (
 	// If present, the code is run every time the user underlines this config directive.
 	runDirective = "#run"
 
+	// lintDirective is an optional directive to specify if the code should be linted.
+	// If not present, the code will never be linted.
+	// If present, the code is linted every time the user underlines this config directive,
+	// and the problems found are highlighted until the code changes.
+	lintDirective = "#lint"
+
+	// lineNumbersDirective is an optional directive to show line numbers before
+	// each line of code, which are updated as lines change and are not part of
+	// the code when it is formatted or run. If not present, no line numbers are shown.
+	lineNumbersDirective = "#linenumbers"
+
+	// diffDirective is an optional directive to specify that the code is a diff,
+	// where the lines added (`+`) and removed (`-`) are highlighted, and the
+	// code of each line is highlighted with the language. The markers and
+	// headers (such as `@@ -1,3 +1,4 @@`) are not part of the code.
+	// If not present, the code is only a diff if its language is Diff.
+	diffDirective = "#diff"
+
 	// FontRegex is an optional directive to specify the font of the code.
 	// If not set, #font=courier_new is assumed by default.
 	fontDirectiveRegex = regexp.MustCompile("^#font=([\\w_]+)$")
@@ -33,13 +52,46 @@ var (
 
 	// LangRegex is the regex for the optional directive
 	// to specify the language of the code.
-	// If not set, #lang=go is assumed by default.
+	// If not set (or #lang=auto), the language is detected from the code.
 	langDirectiveRegex = regexp.MustCompile("^#lang=([\\w_]+)$")
 
+	// autoLang is the language of the lang directive to detect the language.
+	autoLang = "auto"
+
+	// DetectedRegex is the regex for the directive that the bot adds to the header
+	// to report the detected language, such as #detected=go.
+	// It is replaced when the detected language changes.
+	detectedDirectiveRegex = regexp.MustCompile("^#detected=(\\S+)$")
+
+	// HighlightRegex is an optional directive to specify lines of code to highlight
+	// with the theme's line highlight color, such as #highlight=3-7,12.
+	// Lines are numbered from one, and ranges include both of their lines.
+	highlightDirectiveRegex = regexp.MustCompile("^#highlight=(\\d+(-\\d+)?(,\\d+(-\\d+)?)*)$")
+
 	// ShortcutsRegex is an optional directive to specify if shortcuts are enabled.
 	// By default, shortcuts are disabled.
 	shortcutsDirectiveRegex = regexp.MustCompile("^#shortcuts=(enabled|disabled)$")
 
+	// BracketsRegex is an optional directive to specify if brackets are colored
+	// by their depth, where unmatched brackets are colored as errors.
+	// By default, brackets are not colored.
+	bracketsDirectiveRegex = regexp.MustCompile("^#brackets=(enabled|disabled)$")
+
+	// StyleRegex is an optional directive to specify the style of the formatter,
+	// such as #style=google for `clang-format`.
+	// If not set, the formatter's default style is used.
+	styleDirectiveRegex = regexp.MustCompile("^#style=([\\w_]+)$")
+
+	// FixtureRegex is an optional directive to specify the fixture file
+	// that seeds the database before running SQL, such as #fixture=users
+	// for `users.sql` in the fixture directory.
+	fixtureDirectiveRegex = regexp.MustCompile("^#fixture=([\\w_]+)$")
+
+	// SchemaRegex is an optional directive to specify the JSON Schema file
+	// that validates JSON or YAML data when run, such as #schema=config
+	// for `config.json` in the schema directory.
+	schemaDirectiveRegex = regexp.MustCompile("^#schema=([\\w_]+)$")
+
 	// ThemeRegex is an optional directive to specify the theme of the code.
 	// If not set, #theme=dark is assumed by default.
 	themeDirectiveRegex = regexp.MustCompile("^#theme=([\\w_]+)$")
@@ -60,6 +112,85 @@ func (u *UnderlinedDirective) GetRange() *docs.Range {
 	return request.GetRange(u.StartIndex, u.EndIndex, u.SegmentID)
 }
 
+// LineRange describes the one-based lines of code from
+// the start line to the end line (inclusive).
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// Parses comma-separated lines and ranges of lines, such as `3-7,12`.
+func parseLineRanges(s string) ([]LineRange, error) {
+	var lineRanges []LineRange
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, err
+			}
+		}
+		if start < 1 || end < start {
+			return nil, fmt.Errorf("invalid range of lines `%s`", part)
+		}
+		lineRanges = append(lineRanges, LineRange{start, end})
+	}
+	return lineRanges, nil
+}
+
+// ReportDirective describes a directive that reports something to the user
+// as well as its value and the UTF16 indices of the directive (to replace itself).
+type ReportDirective struct {
+	Value      string // reported value
+	SegmentID  string // segment ID
+	StartIndex int64  // start index of directive
+	EndIndex   int64  // end index of directive
+}
+
+// GetRange gets the *docs.Range
+// for a particular ReportDirective.
+func (r *ReportDirective) GetRange() *docs.Range {
+	return request.GetRange(r.StartIndex, r.EndIndex, r.SegmentID)
+}
+
+// ReportDetectedLanguage gets the requests to report the detected language
+// with a #detected directive at the end of the first header, replacing
+// an outdated report, or to remove the report if the language is set.
+// Since the header's indices change, these must be the last requests.
+func (c *CodeInstance) ReportDetectedLanguage() (reqs []*docs.Request) {
+	if !c.Detected {
+		if c.Report != nil {
+			reqs = append(reqs, request.Delete(c.Report.GetRange()))
+		}
+		return
+	}
+
+	detected := strings.ToLower(strings.Join(strings.Fields(c.Lang.Name), "_"))
+	if c.Report != nil {
+		if c.Report.Value != detected {
+			reqs = append(reqs, request.Delete(c.Report.GetRange()))
+			reqs = append(reqs, request.Insert("#detected="+detected, c.Report.StartIndex, c.Report.SegmentID))
+		}
+		return
+	}
+
+	seg, ok := c.Segments[c.headerID]
+	if !ok {
+		log.Printf("No header to report the detected language: `%s`\n", c.Lang.Name)
+		return
+	}
+	// insert before the header's last newline, after a space if not empty
+	text := "#detected=" + detected
+	if seg.EndIndex-seg.StartIndex > 1 {
+		text = " " + text
+	}
+	return append(reqs, request.Insert(text, seg.EndIndex-1, c.headerID))
+}
+
 // Checks for config directives in a particular
 // string that is located in a *docs.ParagraphElement.
 func (c *CodeInstance) checkForDirectives(s, segmentID string, par *docs.ParagraphElement) {
@@ -87,6 +218,43 @@ func (c *CodeInstance) checkForDirectives(s, segmentID string, par *docs.Paragra
 		return
 	}
 
+	// check for lint (must be underlined)
+	if c.Lint == nil && strings.EqualFold(s, lintDirective) {
+		lintStart, lintEnd := getUTF16SubstrIndices(lintDirective, par.TextRun.Content, par.StartIndex)
+		c.Lint = &UnderlinedDirective{
+			Underlined: par.TextRun.TextStyle.Underline,
+			StartIndex: lintStart,
+			EndIndex:   lintEnd,
+			SegmentID:  segmentID,
+		}
+		return
+	}
+
+	// check for line numbers
+	if !c.LineNumbers && strings.EqualFold(s, lineNumbersDirective) {
+		c.LineNumbers = true
+		return
+	}
+
+	// check for diff
+	if !c.Diff && strings.EqualFold(s, diffDirective) {
+		c.Diff = true
+		return
+	}
+
+	// check for highlighted lines
+	if c.Highlights == nil {
+		if res := highlightDirectiveRegex.FindStringSubmatch(s); len(res) == 5 {
+			if lineRanges, err := parseLineRanges(res[1]); err != nil {
+				// TODO: highlight invalid directive
+				log.Printf("Failed to parse highlighted lines `%s`: %s\n", res[1], err)
+			} else {
+				c.Highlights = lineRanges
+			}
+			return
+		}
+	}
+
 	// check for shortcuts
 	if c.Shortcuts == nil {
 		if res := shortcutsDirectiveRegex.FindStringSubmatch(s); len(res) == 2 {
@@ -96,9 +264,35 @@ func (c *CodeInstance) checkForDirectives(s, segmentID string, par *docs.Paragra
 		}
 	}
 
+	// check for brackets
+	if c.Brackets == nil {
+		if res := bracketsDirectiveRegex.FindStringSubmatch(s); len(res) == 2 {
+			enabled := res[1] == "enabled"
+			c.Brackets = &enabled
+			return
+		}
+	}
+
+	// check for the report of the detected language
+	if c.Report == nil {
+		if res := detectedDirectiveRegex.FindStringSubmatch(s); len(res) == 2 {
+			reportStart, reportEnd := getUTF16SubstrIndices(res[0], par.TextRun.Content, par.StartIndex)
+			c.Report = &ReportDirective{
+				Value:      res[1],
+				StartIndex: reportStart,
+				EndIndex:   reportEnd,
+				SegmentID:  segmentID,
+			}
+			return
+		}
+	}
+
 	// check for language
 	if c.Lang == nil {
 		if res := langDirectiveRegex.FindStringSubmatch(s); len(res) == 2 {
+			if strings.EqualFold(res[1], autoLang) {
+				return // detected when setting defaults
+			}
 			if l, ok := style.GetLanguage(res[1]); ok {
 				c.Lang = l
 			} else {
@@ -136,6 +330,30 @@ func (c *CodeInstance) checkForDirectives(s, segmentID string, par *docs.Paragra
 		}
 	}
 
+	// check for formatter style
+	if c.Options.Style == "" {
+		if res := styleDirectiveRegex.FindStringSubmatch(s); len(res) == 2 {
+			c.Options.Style = res[1]
+			return
+		}
+	}
+
+	// check for fixture
+	if c.Options.Fixture == "" {
+		if res := fixtureDirectiveRegex.FindStringSubmatch(s); len(res) == 2 {
+			c.Options.Fixture = res[1]
+			return
+		}
+	}
+
+	// check for schema
+	if c.Options.Schema == "" {
+		if res := schemaDirectiveRegex.FindStringSubmatch(s); len(res) == 2 {
+			c.Options.Schema = res[1]
+			return
+		}
+	}
+
 	// check for theme
 	if c.Theme == nil {
 		if res := themeDirectiveRegex.FindStringSubmatch(s); len(res) == 2 {
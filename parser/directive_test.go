@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"GDocs-Syntax-Highlighter/request"
+	"GDocs-Syntax-Highlighter/runner"
+	"GDocs-Syntax-Highlighter/style"
+	"reflect"
+	"testing"
+
+	"google.golang.org/api/docs/v1"
+)
+
+func TestCheckForOptionDirectives(t *testing.T) {
+	c := new(CodeInstance)
+	for _, s := range []string{"#style=google", "#style=llvm", "#fixture=users", "#schema=tables"} {
+		c.checkForDirectives(s, "", nil)
+	}
+	// the first directive wins
+	want := runner.Options{Style: "google", Fixture: "users", Schema: "tables"}
+	if c.Options != want {
+		t.Errorf("Options = %+v, want %+v", c.Options, want)
+	}
+}
+
+func TestCheckForLanguageDirective(t *testing.T) {
+	tests := []struct {
+		directives []string
+		want       string
+		detected   bool
+	}{
+		{[]string{"#lang=rust"}, "Rust", false},
+		{[]string{"#lang=RS"}, "Rust", false},
+		// the first directive wins
+		{[]string{"#lang=java", "#lang=rust"}, "Java", false},
+		{[]string{"#lang=auto"}, "Go", true},
+		{[]string{"#lang=nope"}, "Go", true},
+		{nil, "Go", true},
+	}
+	for _, tt := range tests {
+		c := &CodeInstance{Code: "package main\n\nfunc main() {}\n"}
+		for _, s := range tt.directives {
+			c.checkForDirectives(s, "", nil)
+		}
+		c.setDefaults()
+		if c.Lang.Name != tt.want || c.Detected != tt.detected {
+			t.Errorf("%v: language = %s, detected %v, want %s, detected %v", tt.directives, c.Lang.Name, c.Detected, tt.want, tt.detected)
+		}
+	}
+}
+
+func TestReportDetectedLanguage(t *testing.T) {
+	java, _ := style.GetLanguage("java")
+	report := &ReportDirective{Value: "go", SegmentID: "h", StartIndex: 3, EndIndex: 13}
+	tests := []struct {
+		name string
+		c    *CodeInstance
+		want []*docs.Request
+	}{
+		{"set language", &CodeInstance{Lang: java}, nil},
+		{"set language with report", &CodeInstance{Lang: java, Report: report}, []*docs.Request{
+			request.Delete(report.GetRange()),
+		}},
+		{"same report", &CodeInstance{Lang: style.DetectLanguage("package main\n"), Detected: true, Report: report}, nil},
+		{"outdated report", &CodeInstance{Lang: java, Detected: true, Report: report}, []*docs.Request{
+			request.Delete(report.GetRange()),
+			request.Insert("#detected=java", 3, "h"),
+		}},
+		{"empty header", &CodeInstance{Lang: java, Detected: true, headerID: "h", Segments: map[string]*ConfigSegment{"h": {0, 1}}}, []*docs.Request{
+			request.Insert("#detected=java", 0, "h"),
+		}},
+		{"header", &CodeInstance{Lang: java, Detected: true, headerID: "h", Segments: map[string]*ConfigSegment{"h": {0, 8}}}, []*docs.Request{
+			request.Insert(" #detected=java", 7, "h"),
+		}},
+		{"no header", &CodeInstance{Lang: java, Detected: true}, nil},
+	}
+	for _, tt := range tests {
+		if got := tt.c.ReportDetectedLanguage(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ReportDetectedLanguage() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseLineRanges(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []LineRange
+		err  bool
+	}{
+		{"3", []LineRange{{3, 3}}, false},
+		{"3-7,12", []LineRange{{3, 7}, {12, 12}}, false},
+		{"1-1,2-3", []LineRange{{1, 1}, {2, 3}}, false},
+		{"0", nil, true},
+		{"7-3", nil, true},
+		{"a", nil, true},
+		{"1-b", nil, true},
+		{"1,,2", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseLineRanges(tt.s)
+		if !reflect.DeepEqual(got, tt.want) || (err != nil) != tt.err {
+			t.Errorf("parseLineRanges(%q) = %v, %v, want %v, error %v", tt.s, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestCheckForHighlightDirective(t *testing.T) {
+	c := new(CodeInstance)
+	// an invalid directive is ignored, and the first valid directive wins
+	for _, s := range []string{"#highlight=7-3", "#highlight=2,4-5", "#highlight=1"} {
+		c.checkForDirectives(s, "", nil)
+	}
+	if want := []LineRange{{2, 2}, {4, 5}}; !reflect.DeepEqual(c.Highlights, want) {
+		t.Errorf("Highlights = %v, want %v", c.Highlights, want)
+	}
+}
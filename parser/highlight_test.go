@@ -0,0 +1,400 @@
+package parser
+
+import (
+	"GDocs-Syntax-Highlighter/style"
+	"fmt"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// A part of the code and the color it is expected to be highlighted with.
+type colored struct {
+	text  string
+	color *docs.Color // nil if it is not highlighted
+}
+
+// Gets a new code instance of ASCII code in a language,
+// which starts at index 1 of the document like the body does.
+func newTestInstance(t *testing.T, lang, code string) *CodeInstance {
+	l, ok := style.GetLanguage(lang)
+	if !ok {
+		t.Fatalf("unknown language `%s`", lang)
+	}
+	startIndex := int64(1)
+	c := &CodeInstance{Code: code, Lang: l, StartIndex: &startIndex}
+	c.setDefaults()
+	c.MapToUTF16()
+	return c
+}
+
+// Gets the foreground color of each byte of ASCII code after applying
+// the requests in order, where later requests win.
+func applyForegroundColors(code string, reqs []*docs.Request) []*docs.Color {
+	colors := make([]*docs.Color, len(code))
+	for _, req := range reqs {
+		u := req.UpdateTextStyle
+		if u == nil || u.TextStyle.ForegroundColor == nil {
+			continue
+		}
+		for i := u.Range.StartIndex; i < u.Range.EndIndex; i++ {
+			colors[i-1] = u.TextStyle.ForegroundColor.Color
+		}
+	}
+	return colors
+}
+
+// Gets the foreground color of each byte of ASCII code in a language,
+// highlighted by a theme like the bot highlights it.
+func getColors(t *testing.T, lang, theme, code string) []*docs.Color {
+	c := newTestInstance(t, lang, code)
+	th, ok := c.Lang.Themes[theme]
+	if !ok {
+		t.Fatalf("language `%s` has no theme `%s`", lang, theme)
+	}
+	reqs := c.RemoveRanges(th)
+	reqs = append(reqs, c.HighlightKeywords(th)...)
+	return applyForegroundColors(code, reqs)
+}
+
+// Gets the hex code of a color for test messages.
+func colorName(c *docs.Color) string {
+	if c == nil {
+		return "none"
+	}
+	rgb := c.RgbColor
+	return fmt.Sprintf("#%02X%02X%02X", int(rgb.Red*255+0.5), int(rgb.Green*255+0.5), int(rgb.Blue*255+0.5))
+}
+
+// Checks that each part of ASCII code in a language is highlighted with its color
+// by a theme, where the parts are searched for in order.
+func checkColors(t *testing.T, lang, theme, code string, want []colored) {
+	t.Helper()
+	colors := getColors(t, lang, theme, code)
+	var pos int
+	for _, w := range want {
+		i := strings.Index(code[pos:], w.text)
+		if i < 0 {
+			t.Errorf("%s: `%s` not found in the code after index %d", lang, w.text, pos)
+			continue
+		}
+		i += pos
+		for j := i; j < i+len(w.text); j++ {
+			if colors[j] != w.color {
+				t.Errorf("%s: `%s` at %d has color %s at `%c`, want %s", lang, w.text, i, colorName(colors[j]), code[j], colorName(w.color))
+				break
+			}
+		}
+		pos = i + len(w.text)
+	}
+}
+
+func TestHighlightJavaScript(t *testing.T) {
+	code := "const s = `a ${b + \"c\"} d`; // note\nlet r = /[/]x/g, q = a / b / c;\nreturn <div>text {x}</div>;\n"
+	checkColors(t, "javascript", "dark", code, []colored{
+		{"const", style.DarkThemeDarkBlue},
+		{"`a ${", style.DarkThemeLightRedOrange},
+		{"b + ", nil},
+		{"\"c\"", style.DarkThemeLightRedOrange},
+		{"} d`", style.DarkThemeLightRedOrange},
+		{"// note", style.DarkThemeDarkGreen},
+		{"/[/]x/g", style.DarkThemeLightRed},
+		{"a / b / c", nil},
+		{"return", style.DarkThemePink},
+		{"<div", style.DarkThemeDarkBlue},
+		{"text ", style.DarkThemeForeground},
+		{"x", nil},
+		// the `<` ends the text
+		{"<", style.DarkThemeForeground},
+		{"/div", style.DarkThemeDarkBlue},
+	})
+}
+
+func TestHighlightTypeScript(t *testing.T) {
+	// generics are not JSX tags in TypeScript
+	code := "let a: Array<number> = f<string>(x);\ninterface I { readonly n: number }\n"
+	checkColors(t, "typescript", "light", code, []colored{
+		{"let", style.Blue},
+		{"Array", style.LightThemeGreenCyan},
+		{"<", nil},
+		{"number", style.LightThemeGreenCyan},
+		{"<", nil},
+		{"string", style.LightThemeGreenCyan},
+		{"interface", style.Blue},
+		{"readonly", style.Blue},
+	})
+}
+
+func TestHighlightC(t *testing.T) {
+	code := "#include <stdio.h>\n/* a /* b */ int x = 'a';\nint main(void) { return printf(\"%d\\n\", 0x1F); } // c \\\ncontinued\n"
+	checkColors(t, "c", "dark", code, []colored{
+		{"#include", style.DarkThemePink},
+		{"<stdio.h>", style.DarkThemeLightRedOrange},
+		// block comments do not nest
+		{"/* a /* b */", style.DarkThemeDarkGreen},
+		{"int", style.DarkThemeGreenCyan},
+		{"'a'", style.DarkThemeLightRedOrange},
+		{"return", style.DarkThemePink},
+		{"printf", style.DarkThemeYellow},
+		{"0x1F", style.DarkThemePaleGreen},
+		{"// c \\\ncontinued", style.DarkThemeDarkGreen},
+	})
+}
+
+func TestHighlightCpp(t *testing.T) {
+	code := "auto s = R\"x(a \")\" b)x\"; std::cout << s;\n"
+	checkColors(t, "cpp", "light", code, []colored{
+		{"auto", style.Blue},
+		{"R\"x(a \")\" b)x\"", style.LightThemeDarkRed},
+		{"std", style.LightThemeGreenCyan},
+		{"cout", style.LightThemeStrawYellow},
+	})
+}
+
+func TestHighlightRust(t *testing.T) {
+	code := "#[derive(Debug)]\nfn f<'a>(s: &'a str) -> char { /* a /* b */ c */ let r = r#\"x\"y\"#; 'c' }\nfn main() { println!(\"{}\", 1u8); }\n"
+	checkColors(t, "rust", "dark", code, []colored{
+		{"#[derive(Debug)]", style.DarkThemeStrawYellow},
+		{"fn", style.DarkThemeDarkBlue},
+		{"'a", style.DarkThemeDarkBlue},
+		{"'a", style.DarkThemeDarkBlue},
+		{"str", style.DarkThemeGreenCyan},
+		// block comments nest
+		{"/* a /* b */ c */", style.DarkThemeDarkGreen},
+		{"let", style.DarkThemeDarkBlue},
+		{"r#\"x\"y\"#", style.DarkThemeLightRedOrange},
+		{"'c'", style.DarkThemeLightRedOrange},
+		{"println!", style.DarkThemeYellow},
+		{"\"{}\"", style.DarkThemeLightRedOrange},
+		{"1u8", style.DarkThemePaleGreen},
+	})
+}
+
+func TestHighlightSQL(t *testing.T) {
+	code := "select \"from\", count(*) from t -- c\nwhere b = 'it''s' and n > 1.5;\n"
+	checkColors(t, "sql", "light", code, []colored{
+		{"select", style.Blue},
+		// a quoted identifier is not a keyword
+		{"\"from\"", style.Black},
+		{"count", style.LightThemeStrawYellow},
+		{"from", style.Blue},
+		{"-- c", style.LightThemeDarkGreen},
+		{"where", style.Blue},
+		{"'it''s'", style.LightThemeDarkRed},
+		{"and", style.LightThemePink},
+		{"1.5", style.LightThemePaleGreen},
+	})
+}
+
+func TestHighlightPostgreSQL(t *testing.T) {
+	code := "select $body$ it's $body$, E'\\'';\n"
+	checkColors(t, "postgres", "light", code, []colored{
+		{"$body$ it's $body$", style.LightThemeDarkRed},
+		{"E'\\''", style.LightThemeDarkRed},
+	})
+}
+
+func TestHighlightBash(t *testing.T) {
+	code := "echo \"$HOME ${a:-x} $(date +%s)\" a#b # c\ncat <<EOF\n$USER\nEOF\ncat <<'EOF'\n$USER\nEOF\n"
+	checkColors(t, "bash", "light", code, []colored{
+		{"echo", style.LightThemeStrawYellow},
+		{"\"", style.LightThemeDarkRed},
+		{"$HOME", style.LightThemeNavy},
+		{"${a:-x}", style.LightThemeNavy},
+		{"$(", style.LightThemeDarkRed},
+		{"date +%s", nil},
+		{")\"", style.LightThemeDarkRed},
+		// a comment starts at a word
+		{"a#b", nil},
+		{"# c", style.LightThemeDarkGreen},
+		{"<<EOF\n", style.LightThemeDarkRed},
+		{"$USER", style.LightThemeNavy},
+		{"\nEOF\n", style.LightThemeDarkRed},
+		// a quoted heredoc does not expand variables
+		{"<<'EOF'\n$USER\nEOF\n", style.LightThemeDarkRed},
+	})
+}
+
+func TestHighlightJSON(t *testing.T) {
+	code := "{\"key\": \"v\\n\", \"n\": -1.5e3, \"b\": [true, null]}\n"
+	checkColors(t, "json", "dark", code, []colored{
+		{"\"key\"", style.DarkThemeLightBlue},
+		{"\"v", style.DarkThemeLightRedOrange},
+		{"\"n\"", style.DarkThemeLightBlue},
+		{"-1.5e3", style.DarkThemePaleGreen},
+		{"true", style.DarkThemeDarkBlue},
+		{"null", style.DarkThemeDarkBlue},
+	})
+}
+
+func TestHighlightYAML(t *testing.T) {
+	code := "key: value # c\nlist:\n  - &a 'x'\n  - *a\nn: 12\nb: true\n"
+	checkColors(t, "yaml", "light", code, []colored{
+		{"key", style.LightThemeMaroon},
+		{"value", nil},
+		{"# c", style.LightThemeDarkGreen},
+		{"list", style.LightThemeMaroon},
+		{"&a", style.LightThemeGreenCyan},
+		{"'x'", style.Blue},
+		{"*a", style.LightThemeGreenCyan},
+		{"12", style.LightThemePaleGreen},
+		{"true", style.Blue},
+	})
+}
+
+func TestHighlightJava(t *testing.T) {
+	code := "@Override\npublic String s() { List<String> l; char c = '\\n'; return \"\"\"\n  a \"b\" \\t\n  \"\"\"; }\n"
+	checkColors(t, "java", "dark", code, []colored{
+		{"@Override", style.DarkThemeStrawYellow},
+		{"public", style.DarkThemeDarkBlue},
+		{"String", style.DarkThemeGreenCyan},
+		{"List", style.DarkThemeGreenCyan},
+		// generics are types
+		{"String", style.DarkThemeGreenCyan},
+		{"char", style.DarkThemeGreenCyan},
+		{"'", style.DarkThemeLightRedOrange},
+		{"\\n", style.DarkThemeStrawYellow},
+		{"return", style.DarkThemePink},
+		// a text block may contain unescaped quotes
+		{"\"\"\"\n  a \"b\" ", style.DarkThemeLightRedOrange},
+		{"\\t", style.DarkThemeStrawYellow},
+		{"\n  \"\"\"", style.DarkThemeLightRedOrange},
+	})
+}
+
+func TestHighlightKotlin(t *testing.T) {
+	code := "fun main() { val s = \"$name ${a + 1}\"; /* a /* b */ c */ this@Outer }\n"
+	checkColors(t, "kotlin", "light", code, []colored{
+		{"fun", style.Blue},
+		{"val", style.Blue},
+		{"\"", style.LightThemeDarkRed},
+		{"$name", style.LightThemeNavy},
+		{"${", style.LightThemeDarkRed},
+		{"a + ", nil},
+		{"1", style.LightThemePaleGreen},
+		{"}\"", style.LightThemeDarkRed},
+		// block comments nest
+		{"/* a /* b */ c */", style.LightThemeDarkGreen},
+		{"this", style.Blue},
+		// a label is not an annotation
+		{"@", nil},
+	})
+}
+
+func TestHighlightCSS(t *testing.T) {
+	code := "a:hover, .x #y { color: #fff; width: calc(100% - 2px) !important; }\n"
+	checkColors(t, "css", "dark", code, []colored{
+		// a selector is not a property
+		{"a", nil},
+		{":hover", style.DarkThemeStrawYellow},
+		{".x", style.DarkThemeStrawYellow},
+		{"#y", style.DarkThemeStrawYellow},
+		{"color", style.DarkThemeLightBlue},
+		{"#fff", style.DarkThemePaleGreen},
+		{"width", style.DarkThemeLightBlue},
+		{"calc", style.DarkThemeYellow},
+		{"100%", style.DarkThemePaleGreen},
+		{"2px", style.DarkThemePaleGreen},
+		{"!important", style.DarkThemePink},
+	})
+}
+
+func TestHighlightHTML(t *testing.T) {
+	code := "<p class=\"a\" hidden>&amp; x</p><!-- c -->\n<style>p { color: red; }</style>\n<script>let s = \"</p>\";</script>\n"
+	checkColors(t, "html", "dark", code, []colored{
+		{"<p", style.DarkThemeDarkBlue},
+		{"class", style.DarkThemeLightBlue},
+		{"=", style.DarkThemeDarkBlue},
+		{"\"a\"", style.DarkThemeLightRedOrange},
+		{"hidden", style.DarkThemeLightBlue},
+		{">", style.DarkThemeDarkBlue},
+		{"&amp;", style.DarkThemeLightBlue},
+		{" x", nil},
+		{"</p>", style.DarkThemeDarkBlue},
+		{"<!-- c -->", style.DarkThemeDarkGreen},
+		{"<style>", style.DarkThemeDarkBlue},
+		// the style element's code is highlighted as CSS
+		{"color", style.DarkThemeLightBlue},
+		{"</style>", style.DarkThemeDarkBlue},
+		{"<script>", style.DarkThemeDarkBlue},
+		// the script element's code is highlighted as JavaScript
+		{"let", style.DarkThemeDarkBlue},
+		{"\"</p>\"", style.DarkThemeLightRedOrange},
+		{"</script>", style.DarkThemeDarkBlue},
+	})
+}
+
+func TestHighlightMarkdown(t *testing.T) {
+	code := "# Title\nsome **bold** and snake_case_name, `a**b**` [link](http://x)\n- item\n```go\nfunc main() {}\n```\n---\n"
+	checkColors(t, "markdown", "dark", code, []colored{
+		{"# Title", style.DarkThemeDarkBlue},
+		{"some ", nil},
+		{"**bold**", style.DarkThemeDarkBlue},
+		// underscores inside a word are not emphasis
+		{"snake_case_name", nil},
+		// inline code has no emphasis
+		{"`a**b**`", style.DarkThemeLightRedOrange},
+		{"[link]", style.DarkThemeLightBlue},
+		{"(http://x)", style.DarkThemeLightRedOrange},
+		{"- ", style.DarkThemeCornflowerBlue},
+		{"```go", style.DarkThemeLightRedOrange},
+		// the fenced code is highlighted as its language
+		{"func", style.DarkThemeDarkBlue},
+		{"main", style.DarkThemeYellow},
+		{"\n```", style.DarkThemeLightRedOrange},
+		{"---", style.DarkThemeDarkGreen},
+	})
+}
+
+func TestHighlightMarkdownUnknownFence(t *testing.T) {
+	// the code of a fence of an unknown language is not highlighted
+	code := "```nope\nfunc main() {}\n```\n"
+	checkColors(t, "markdown", "light", code, []colored{
+		{"```nope", style.LightThemeMaroon},
+		{"\nfunc main() {}", nil},
+		{"\n```", style.LightThemeMaroon},
+	})
+}
+
+func TestHighlightGo(t *testing.T) {
+	code := "package main\n\ntype T struct{}\n\nfunc (t T) Name() string { return fmt.Sprintf(\"%-8.2f\\n\", 1) }\n"
+	checkColors(t, "go", "dark", code, []colored{
+		{"package", style.DarkThemeDarkBlue},
+		{"type", style.DarkThemeDarkBlue},
+		// only the names are highlighted, not their context
+		{"T", style.DarkThemeGreenCyan},
+		{"struct", style.DarkThemeDarkBlue},
+		{"func", style.DarkThemeDarkBlue},
+		{" (t T) ", nil},
+		{"Name", style.DarkThemeYellow},
+		{"()", nil},
+		{"string", style.DarkThemeGreenCyan},
+		{"return", style.DarkThemePink},
+	})
+}
+
+func TestHighlightEscapesAndVerbs(t *testing.T) {
+	code := "s := \"a\\q %z \\n %-8.2f %%\" + `%v \\n`\n"
+	checkColors(t, "go", "dark", code, []colored{
+		{"\"a", style.DarkThemeLightRedOrange},
+		// invalid escapes and verbs are not highlighted
+		{"\\q %z ", style.DarkThemeLightRedOrange},
+		{"\\n", style.DarkThemeStrawYellow},
+		{" ", style.DarkThemeLightRedOrange},
+		{"%-8.2f", style.DarkThemeLightBlue},
+		{" ", style.DarkThemeLightRedOrange},
+		{"%%", style.DarkThemeLightBlue},
+		{"\"", style.DarkThemeLightRedOrange},
+		// a raw string has verbs but no escapes
+		{"`", style.DarkThemeLightRedOrange},
+		{"%v", style.DarkThemeLightBlue},
+		{" \\n`", style.DarkThemeLightRedOrange},
+	})
+	checkColors(t, "java", "light", "char c = '\\q'; String s = \"\\477\";\n", []colored{
+		{"'\\q'", style.LightThemeDarkRed},
+		{"\"", style.LightThemeDarkRed},
+		{"\\47", style.LightThemeBrightRed},
+		{"7\"", style.LightThemeDarkRed},
+	})
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"GDocs-Syntax-Highlighter/request"
+	"GDocs-Syntax-Highlighter/runner"
 	"GDocs-Syntax-Highlighter/style"
 	"strings"
 
@@ -18,18 +19,30 @@ type ConfigSegment struct {
 // CodeInstance describes a section in the Google Doc
 // that has a config and code fragment.
 type CodeInstance struct {
-	toUTF16    map[int]int64             // maps the indices of the zero-based utf8 rune in Code to utf16 rune indices+start utf16 offset
-	Segments   map[string]*ConfigSegment // headers and footer IDs -> config segment
-	Code       string                    // the code as text
-	Theme      *string                   // theme
-	Font       *string                   // font
-	FontSize   *float64                  // font size
-	Lang       *style.Language           // the coding language
-	StartIndex *int64                    // utf16 start index of code
-	EndIndex   *int64                    // utf16 end index of code
-	Shortcuts  *bool                     // whether shortcuts are enabled
-	Format     *UnderlinedDirective      // whether we are being requested to format the code
-	Run        *UnderlinedDirective      // whether we are being requested to run the code
+	toUTF16     map[int]int64             // maps the indices of the zero-based utf8 rune in Code to utf16 rune indices+start utf16 offset
+	regions     []region                  // embedded code in Code, highlighted with the keywords of another theme
+	lineNumbers []string                  // line numbers in the document before each line of Code, which are not part of it
+	diffMarkers []string                  // diff markers (or headers) in the document after the line numbers of each line of Code, which are not part of it
+	Segments    map[string]*ConfigSegment // headers and footer IDs -> config segment
+	Code        string                    // the code as text
+	Theme       *string                   // theme
+	Font        *string                   // font
+	FontSize    *float64                  // font size
+	Lang        *style.Language           // the coding language
+	Detected    bool                      // whether the language was detected, since it is not set (or is #lang=auto)
+	Report      *ReportDirective          // the #detected directive reporting the detected language, if any
+	headerID    string                    // the first header, where the detected language is reported
+	StartIndex  *int64                    // utf16 start index of code
+	EndIndex    *int64                    // utf16 end index of code
+	Shortcuts   *bool                     // whether shortcuts are enabled
+	Brackets    *bool                     // whether brackets are colored by their depth
+	LineNumbers bool                      // whether line numbers are shown before each line of code
+	Highlights  []LineRange               // lines of code to highlight
+	Diff        bool                      // whether the code is a diff, whose lines are colored by their change
+	Format      *UnderlinedDirective      // whether we are being requested to format the code
+	Run         *UnderlinedDirective      // whether we are being requested to run the code
+	Lint        *UnderlinedDirective      // whether we are being requested to lint the code
+	Options     runner.Options            // options passed to the language's formatter and runner
 }
 
 // GetRange gets the *docs.Range
@@ -46,12 +59,14 @@ func (c *CodeInstance) GetTheme() *style.Theme {
 
 // UpdateCode gets the []*docs.Request to delete the existing
 // code range and replace it with a new string Code.
-// It does not update the indices.
+// It does not update the indices, and since any line numbers and diff markers
+// are replaced too, the code has none after.
 func (c *CodeInstance) UpdateCode() []*docs.Request {
+	c.lineNumbers, c.diffMarkers = nil, nil
 	return []*docs.Request{
 		// need to ignore the newline character at the end of the segment so we use EndIndex-1
 		request.Delete(request.GetRange(*c.StartIndex, *c.EndIndex-1, "")),
-		request.Insert(c.Code, *c.StartIndex),
+		request.Insert(c.Code, *c.StartIndex, ""),
 	}
 }
 
@@ -59,7 +74,11 @@ func (c *CodeInstance) UpdateCode() []*docs.Request {
 // Does not set start/end indices.
 func (c *CodeInstance) setDefaults() {
 	if c.Lang == nil {
-		c.Lang = style.GetDefaultLanguage()
+		c.Lang = style.DetectLanguage(c.Code)
+		c.Detected = true
+	}
+	if c.Lang.Diff {
+		c.Diff = true
 	}
 	if c.Format == nil {
 		c.Format = &UnderlinedDirective{}
@@ -67,6 +86,9 @@ func (c *CodeInstance) setDefaults() {
 	if c.Run == nil {
 		c.Run = &UnderlinedDirective{}
 	}
+	if c.Lint == nil {
+		c.Lint = &UnderlinedDirective{}
+	}
 	if c.Font == nil {
 		defaultFont := style.DefaultFont
 		c.Font = &defaultFont
@@ -83,6 +105,10 @@ func (c *CodeInstance) setDefaults() {
 		defaultShortcuts := style.DefaultShortcutSetting
 		c.Shortcuts = &defaultShortcuts
 	}
+	if c.Brackets == nil {
+		defaultBrackets := style.DefaultBracketSetting
+		c.Brackets = &defaultBrackets
+	}
 	if c.toUTF16 == nil {
 		c.toUTF16 = make(map[int]int64)
 	}
@@ -97,6 +123,9 @@ func GetCodeInstance(doc *docs.Document) *CodeInstance {
 
 	// check for config in Google Doc headers
 	for _, h := range doc.Headers {
+		if c.headerID == "" || h.HeaderId < c.headerID {
+			c.headerID = h.HeaderId
+		}
 		for _, elem := range h.Content {
 			if elem.Paragraph != nil {
 				for _, par := range elem.Paragraph.Elements {
@@ -153,8 +182,16 @@ func GetCodeInstance(doc *docs.Document) *CodeInstance {
 	}
 	c.Code = b.String()
 
+	// strip line numbers, which are not part of the code
+	c.stripLineNumbers()
+
 	// set defaults
 	c.setDefaults()
 
+	// strip diff markers, which are not part of the code either
+	if c.Diff {
+		c.stripDiffMarkers()
+	}
+
 	return c
 }
@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"GDocs-Syntax-Highlighter/request"
+	"GDocs-Syntax-Highlighter/style"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// The line number before a line of code, such as ` 7 │ `,
+	// which is right-aligned to the widest line number.
+	lineNumberRegex = regexp.MustCompile("^ *\\d+ │ ")
+)
+
+// Gets the line number before a line of code,
+// right-aligned to a width of digits.
+func getLineNumber(line, width int) string {
+	return fmt.Sprintf("%*d │ ", width, line)
+}
+
+// Gets the lines of code, where each line ends with
+// its newline (besides a last line without one).
+func getLines(code string) []string {
+	lines := strings.SplitAfter(code, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Strips the line numbers before the lines of the instance's Code, keeping
+// them so that the code can be mapped to the document.
+// Line numbers are stripped even if they are disabled, so that they are removed.
+func (c *CodeInstance) stripLineNumbers() {
+	var b strings.Builder
+	c.lineNumbers = nil
+	for _, line := range getLines(c.Code) {
+		lineNumber := lineNumberRegex.FindString(line)
+		c.lineNumbers = append(c.lineNumbers, lineNumber)
+		_, err := b.WriteString(line[len(lineNumber):])
+		check(err)
+	}
+	c.Code = b.String()
+}
+
+// Gets the prefix of a zero-based line of Code, which is empty if there is none.
+func getPrefix(prefixes []string, line int) string {
+	if line < len(prefixes) {
+		return prefixes[line]
+	}
+	return ""
+}
+
+// Gets the prefixes of the lines of Code after lines from the line after a zero-based
+// line are replaced by inserted lines, which have no prefixes.
+func splicePrefixes(prefixes []string, line, removed, inserted int) []string {
+	if line+1 >= len(prefixes) {
+		return prefixes
+	}
+	rest := line + 1 + removed
+	if rest > len(prefixes) {
+		rest = len(prefixes)
+	}
+	return append(append(prefixes[:line+1:line+1], make([]string, inserted)...), prefixes[rest:]...)
+}
+
+// Gets the text in the document before a zero-based line of Code
+// that is not part of it, which is its line number and its diff marker.
+func (c *CodeInstance) getGutter(line int) string {
+	return getPrefix(c.lineNumbers, line) + getPrefix(c.diffMarkers, line)
+}
+
+// Gets the utf16 size of the line numbers in the document
+// before the zero-based lines of Code from start to end.
+func (c *CodeInstance) getGutterSize(start, end int) (size int64) {
+	for line := start; line < end; line++ {
+		size += GetUtf16StringSize(c.getGutter(line))
+	}
+	return
+}
+
+// Gets the utf16 indices in the document of the code between utf8 indices of Code,
+// including the line numbers before and inside it. Unlike the utf16 mapping,
+// it can be used while the code is being changed.
+func (c *CodeInstance) getUTF16Indices(utf8Start, utf8End int) (utf16Start, utf16End int64) {
+	line := strings.Count(c.Code[:utf8Start], "\n")
+	utf16Start = *c.StartIndex + GetUtf16StringSize(c.Code[:utf8Start]) + c.getGutterSize(0, line+1)
+
+	// the code includes the line numbers of the lines after its first line
+	code := c.Code[utf8Start:utf8End]
+	lines := strings.Count(code, "\n")
+	utf16End = utf16Start + GetUtf16StringSize(code) + c.getGutterSize(line+1, line+1+lines)
+	return
+}
+
+// Gets the utf16 range in the document of the non-empty code between
+// utf8 indices of Code, including the line numbers inside it.
+// The code must be mapped to utf16.
+func (c *CodeInstance) getUTF16Range(utf8Start, utf8End int, segmentID string) *docs.Range {
+	last, size := utf8.DecodeLastRuneInString(c.Code[:utf8End])
+	return request.GetRange(c.toUTF16[utf8Start], c.toUTF16[utf8End-size]+GetUtf16RuneSize(last), segmentID)
+}
+
+// Gets the utf16 ranges in the document of the non-empty code between utf8 indices
+// of Code, split where the code is not contiguous in the document, so that the
+// line numbers and diff markers of its lines are not in them.
+// The code must be mapped to utf16.
+func (c *CodeInstance) getUTF16Ranges(utf8Start, utf8End int, segmentID string) (ranges []*docs.Range) {
+	start := c.toUTF16[utf8Start]
+	end := start
+	for i, r := range c.Code[utf8Start:utf8End] {
+		if index := c.toUTF16[utf8Start+i]; index != end {
+			ranges = append(ranges, request.GetRange(start, end, segmentID))
+			start = index
+		}
+		end = c.toUTF16[utf8Start+i] + GetUtf16RuneSize(r)
+	}
+	return append(ranges, request.GetRange(start, end, segmentID))
+}
+
+// UpdateLineNumbers gets the requests to update the line numbers before the lines
+// of code if they are enabled (or to remove them if not), and to color them with
+// the theme's line number color. It must be called before any ranges are removed.
+// Since the line numbers are inserted and deleted, these must be the last requests of the code,
+// and the instance's line numbers are those of the document after the requests.
+func (c *CodeInstance) UpdateLineNumbers(t *style.Theme) (reqs []*docs.Request) {
+	lines := getLines(c.Code)
+	width := len(strconv.Itoa(len(lines)))
+	lineNumbers := make([]string, len(lines))
+	if c.LineNumbers {
+		for i := range lines {
+			lineNumbers[i] = getLineNumber(i+1, width)
+		}
+	}
+
+	// replace the outdated line numbers from the last line,
+	// so that the indices of the lines before it do not change
+	var lineStart int
+	lineStarts := make([]int, len(lines))
+	for i, line := range lines {
+		lineStarts[i] = lineStart
+		lineStart += len(line)
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		old := getPrefix(c.lineNumbers, i)
+		if old == lineNumbers[i] {
+			continue
+		}
+		// the line number is before the diff marker
+		utf16Start := c.toUTF16[lineStarts[i]] - GetUtf16StringSize(c.getGutter(i))
+		if old != "" {
+			reqs = append(reqs, request.Delete(request.GetRange(utf16Start, utf16Start+GetUtf16StringSize(old), "")))
+		}
+		if lineNumbers[i] != "" {
+			reqs = append(reqs, request.Insert(lineNumbers[i], utf16Start, ""))
+		}
+	}
+
+	// color the line numbers at their updated indices
+	utf16Index := *c.StartIndex
+	for i, line := range lines {
+		size := GetUtf16StringSize(lineNumbers[i])
+		if size > 0 {
+			reqs = append(reqs, request.UpdateForegroundColor(t.LineNumberForeground, request.GetRange(utf16Index, utf16Index+size, "")))
+		}
+		utf16Index += size + GetUtf16StringSize(getPrefix(c.diffMarkers, i)+line)
+	}
+
+	// the gutters are now those of the document after the update
+	c.lineNumbers = lineNumbers
+	return
+}
+
+// HighlightLines gets the requests to highlight the background of the lines of code
+// in the instance's highlighted ranges of lines with the theme's line highlight color,
+// where lines past the end of the code are ignored. It must be called before any ranges are removed.
+func (c *CodeInstance) HighlightLines(t *style.Theme) (reqs []*docs.Request) {
+	lines := getLines(c.Code)
+	var lineStart int
+	for i, line := range lines {
+		for _, r := range c.Highlights {
+			if r.Start <= i+1 && i+1 <= r.End {
+				reqs = append(reqs, request.UpdateBackgroundColor(t.LineHighlight, c.getUTF16Range(lineStart, lineStart+len(line), "")))
+				break
+			}
+		}
+		lineStart += len(line)
+	}
+	return
+}
@@ -0,0 +1,239 @@
+package parser
+
+import (
+	"GDocs-Syntax-Highlighter/style"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// Gets a new code instance of a document body without surrogate pairs, which starts at index 1,
+// where the line numbers and diff markers are stripped like the bot strips them.
+func newDocInstance(t *testing.T, lang, doc string) *CodeInstance {
+	l, ok := style.GetLanguage(lang)
+	if !ok {
+		t.Fatalf("unknown language `%s`", lang)
+	}
+	startIndex := int64(1)
+	c := &CodeInstance{Code: doc, Lang: l, StartIndex: &startIndex}
+	c.stripLineNumbers()
+	c.setDefaults()
+	if c.Diff {
+		c.stripDiffMarkers()
+	}
+	c.MapToUTF16()
+	return c
+}
+
+// Applies the requests that insert and delete text to a document body
+// without surrogate pairs (so a rune is a utf16 code unit), which starts at index 1.
+func applyTextRequests(doc string, reqs []*docs.Request) string {
+	runes := []rune(doc)
+	for _, req := range reqs {
+		switch {
+		case req.InsertText != nil:
+			i := req.InsertText.Location.Index - 1
+			runes = append(runes[:i:i], append([]rune(req.InsertText.Text), runes[i:]...)...)
+		case req.DeleteContentRange != nil:
+			r := req.DeleteContentRange.Range
+			runes = append(runes[:r.StartIndex-1:r.StartIndex-1], runes[r.EndIndex-1:]...)
+		}
+	}
+	return string(runes)
+}
+
+// Gets the document body of the instance's Code and its gutters.
+func getDoc(c *CodeInstance) string {
+	var b strings.Builder
+	for i, line := range getLines(c.Code) {
+		b.WriteString(c.getGutter(i) + line)
+	}
+	return b.String()
+}
+
+// Gets the utf16 range and the color of each request
+// that shades the background of paragraphs.
+func getShadings(reqs []*docs.Request) (ranges [][2]int64, colors []*docs.Color) {
+	for _, req := range reqs {
+		if u := req.UpdateParagraphStyle; u != nil && u.ParagraphStyle.Shading != nil {
+			ranges = append(ranges, [2]int64{u.Range.StartIndex, u.Range.EndIndex})
+			colors = append(colors, u.ParagraphStyle.Shading.BackgroundColor.Color)
+		}
+	}
+	return
+}
+
+func TestGetLines(t *testing.T) {
+	tests := []struct {
+		code string
+		want []string
+	}{
+		{"a\nb\n", []string{"a\n", "b\n"}},
+		{"a\nb", []string{"a\n", "b"}},
+		{"\n\n", []string{"\n", "\n"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := getLines(tt.code); !reflect.DeepEqual(got, tt.want) && !(len(got) == 0 && len(tt.want) == 0) {
+			t.Errorf("getLines(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestStripLineNumbers(t *testing.T) {
+	doc := " 9 │ a\n10 │ b │ c\nd 1 │ e\n1 │ \n"
+	c := &CodeInstance{Code: doc}
+	c.stripLineNumbers()
+	if c.Code != "a\nb │ c\nd 1 │ e\n\n" {
+		t.Errorf("Code = %q", c.Code)
+	}
+	if want := []string{" 9 │ ", "10 │ ", "", "1 │ "}; !reflect.DeepEqual(c.lineNumbers, want) {
+		t.Errorf("lineNumbers = %q, want %q", c.lineNumbers, want)
+	}
+	// the stripped line numbers restore the document
+	if got := getDoc(c); got != doc {
+		t.Errorf("document = %q, want %q", got, doc)
+	}
+}
+
+func TestSplicePrefixes(t *testing.T) {
+	prefixes := []string{"1", "2", "3", "4"}
+	tests := []struct {
+		name                    string
+		line, removed, inserted int
+		want                    []string
+	}{
+		{"same line", 1, 0, 0, []string{"1", "2", "3", "4"}},
+		{"insert", 1, 0, 2, []string{"1", "2", "", "", "3", "4"}},
+		{"remove", 0, 2, 0, []string{"1", "4"}},
+		{"replace", 1, 1, 1, []string{"1", "2", "", "4"}},
+		{"remove past the end", 2, 5, 1, []string{"1", "2", "3", ""}},
+		// the lines after the prefixes have none
+		{"last line", 3, 0, 1, []string{"1", "2", "3", "4"}},
+		{"past the end", 7, 1, 1, []string{"1", "2", "3", "4"}},
+	}
+	for _, tt := range tests {
+		got := splicePrefixes(append([]string(nil), prefixes...), tt.line, tt.removed, tt.inserted)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: splicePrefixes(%d, %d, %d) = %q, want %q", tt.name, tt.line, tt.removed, tt.inserted, got, tt.want)
+		}
+	}
+
+	// splicing does not change the prefixes it was given
+	before := []string{"1", "2", "3"}
+	splicePrefixes(before[:2], 0, 0, 1)
+	if before[2] != "3" {
+		t.Errorf("splicePrefixes() changed the prefixes after its slice: %q", before)
+	}
+}
+
+func TestReplaceKeepsLineNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		shortcut *style.Shortcut
+		want     string // the document after the replacements
+	}{
+		{"within a line", "1 │ a X b\n2 │ X\n", &style.Shortcut{Regex: regexp.MustCompile("X"), Replace: "yy"},
+			"1 │ a yy b\n2 │ yy\n"},
+		{"insert lines", "1 │ a X b\n2 │ c\n", &style.Shortcut{Regex: regexp.MustCompile("X"), Replace: "{\n\t\n}"},
+			"1 │ a {\n\t\n} b\n2 │ c\n"},
+		{"remove lines", "1 │ a {\n2 │ \n3 │ } b\n4 │ c\n", &style.Shortcut{Regex: regexp.MustCompile("\\{\n\n\\}"), Replace: "X"},
+			"1 │ a X b\n4 │ c\n"},
+		{"diff markers", "1 │ +a X\n2 │ -b\n3 │  c\n", &style.Shortcut{Regex: regexp.MustCompile("X\nb"), Replace: "\n\nd"},
+			"1 │ +a \n\nd\n3 │  c\n"},
+	}
+	for _, tt := range tests {
+		lang := "text"
+		if strings.Contains(tt.name, "diff") {
+			lang = "diff"
+		}
+		c := newDocInstance(t, lang, tt.doc)
+		doc := applyTextRequests(tt.doc, c.Replace(tt.shortcut))
+		if doc != tt.want {
+			t.Errorf("%s: document = %q, want %q", tt.name, doc, tt.want)
+		}
+		// the gutters of the replaced code are the document's
+		if got := getDoc(c); got != doc {
+			t.Errorf("%s: document of the code = %q, want %q", tt.name, got, doc)
+		}
+		if wantEnd := int64(1 + len([]rune(doc))); *c.EndIndex != wantEnd {
+			t.Errorf("%s: EndIndex = %d, want %d", tt.name, *c.EndIndex, wantEnd)
+		}
+	}
+}
+
+func TestUpdateLineNumbers(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		enabled bool
+		want    string
+	}{
+		{"enable", "a\nb\n", true, "1 │ a\n2 │ b\n"},
+		{"disable", "1 │ a\n2 │ b\n", false, "a\nb\n"},
+		{"up to date", "1 │ a\n2 │ b\n", true, "1 │ a\n2 │ b\n"},
+		{"inserted lines", "1 │ a\nx\ny\n2 │ b\n", true, "1 │ a\n2 │ x\n3 │ y\n4 │ b\n"},
+		{"removed lines", "1 │ a\n3 │ b\n", true, "1 │ a\n2 │ b\n"},
+		{"wider", "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n", true, " 1 │ a\n 2 │ b\n 3 │ c\n 4 │ d\n 5 │ e\n 6 │ f\n 7 │ g\n 8 │ h\n 9 │ i\n10 │ j\n"},
+		{"narrower", " 9 │ a\n10 │ b\n", true, "1 │ a\n2 │ b\n"},
+		{"diff markers", "+a\n1 │ -b\n", true, "1 │ +a\n2 │ -b\n"},
+	}
+	for _, tt := range tests {
+		lang := "text"
+		if strings.Contains(tt.name, "diff") {
+			lang = "diff"
+		}
+		c := newDocInstance(t, lang, tt.doc)
+		c.LineNumbers = tt.enabled
+		th := c.GetTheme()
+		reqs := c.UpdateLineNumbers(th)
+		if got := applyTextRequests(tt.doc, reqs); got != tt.want {
+			t.Errorf("%s: document = %q, want %q", tt.name, got, tt.want)
+			continue
+		}
+
+		// the line numbers are colored at their updated indices
+		runes := []rune(tt.want)
+		var colored []string
+		for _, req := range reqs {
+			if u := req.UpdateTextStyle; u != nil && u.TextStyle.ForegroundColor.Color == th.LineNumberForeground {
+				colored = append(colored, string(runes[u.Range.StartIndex-1:u.Range.EndIndex-1]))
+			}
+		}
+		var want []string
+		for _, line := range getLines(tt.want) {
+			if n := lineNumberRegex.FindString(line); n != "" {
+				want = append(want, n)
+			}
+		}
+		if !reflect.DeepEqual(colored, want) {
+			t.Errorf("%s: colored line numbers = %q, want %q", tt.name, colored, want)
+		}
+	}
+}
+
+func TestHighlightLines(t *testing.T) {
+	// the line numbers and diff markers of highlighted lines are not highlighted
+	doc := "1 │ +a\n2 │ -b\n3 │  c\n4 │ +d"
+	c := newDocInstance(t, "diff", doc)
+	c.Highlights = []LineRange{{2, 3}, {3, 3}, {4, 9}, {12, 12}}
+	th := c.GetTheme()
+	ranges, colors := getShadings(c.HighlightLines(th))
+
+	runes := []rune(doc)
+	var highlighted []string
+	for i, r := range ranges {
+		highlighted = append(highlighted, string(runes[r[0]-1:r[1]-1]))
+		if colors[i] != th.LineHighlight {
+			t.Errorf("line highlight %d color = %s, want %s", i, colorName(colors[i]), colorName(th.LineHighlight))
+		}
+	}
+	// each line is highlighted once, with its newline
+	if want := []string{"b\n", "c\n", "d"}; !reflect.DeepEqual(highlighted, want) {
+		t.Errorf("highlighted lines = %q, want %q", highlighted, want)
+	}
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"GDocs-Syntax-Highlighter/style"
 	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -14,6 +15,8 @@ type parser func(parserInput) parserOutput
 type parserInput interface {
 	current() (*rune, int)   // return current rune, its size
 	advance(int) parserInput // advance based on rune size
+	rest() string            // return the runes that have not been parsed
+	line() string            // return the parsed runes on the current line
 }
 
 // The parsed result and the remaining stream.
@@ -52,33 +55,331 @@ func selectAny(parsers []parser) parser {
 	}
 }
 
+// Maps the result of a successful parser.
+func mapResult(p parser, f func(interface{}) interface{}) parser {
+	return func(in parserInput) parserOutput {
+		out := p(in)
+		if out.result == nil {
+			return out
+		}
+		return success(f(out.result), out.remaining)
+	}
+}
+
+// Represents the end symbol of a range.
+type rangeEnd string
+
+// Represents a nested start symbol of a range.
+type rangeNestedStart string
+
+// Represents the end of the line of a single line range.
+type rangeLineEnd struct{}
+
+// Represents an escape symbol and the rune it escapes.
+type escaped string
+
+// Represents an interpolation inside a range.
+type interpolationOutput struct {
+	start string        // start symbol
+	code  string        // code between the symbols, including any nested ranges
+	spans []removeRange // nested ranges, relative to the start of the code
+	end   string        // end symbol, empty if the end was reached
+}
+
 // Parser for a symbol range.
-// The parser returns
-func expectRange(r *style.Range) parser {
+// The parser returns a rangeOutput whose spans are the colored parts
+// of the range, relative to its start. Interpolations are not part
+// of any span (besides their symbols), and are searched for nested
+// ranges using the inner parser. Inner ranges have their own spans.
+func expectRange(r *style.Range, inner parser) parser {
+	if r.Pattern != nil {
+		return expectPattern(r)
+	}
+	if r.Embed != nil || r.EmbedTheme != "" {
+		return expectEmbed(r)
+	}
+
+	// parsers that stop the search for the end symbol, besides the end symbol itself
+	var others []parser
+	if r.Nested {
+		others = append(others, mapResult(expectString(r.StartSymbol), func(res interface{}) interface{} {
+			return rangeNestedStart(res.(string))
+		}))
+	}
+	// inner ranges before escapes, so that an inner range can be an escape sequence
+	for _, ir := range r.Inner {
+		others = append(others, expectRange(ir, inner))
+	}
+	if r.Escape != "" {
+		others = append(others, expectEscape(r.Escape))
+	}
+	for _, i := range r.Interpolations {
+		others = append(others, expectInterpolation(i, inner))
+	}
+	if r.SingleLine {
+		others = append(others, expectLineEnd())
+	}
+
+	startParser := expectStart(r)
 	return func(in parserInput) parserOutput {
 		// check for start symbol
-		out := expectString(r.StartSymbol)(in)
-		if out.result == nil {
+		out := startParser(in)
+		if out.result == nil || !follows(r.Follows, in) {
 			return fail()
 		}
 		in = out.remaining
-		var b strings.Builder
-		_, err := b.WriteString(r.StartSymbol)
-		check(err)
+		start := out.result.(rangeStart)
+		end := mapResult(expectString(start.end), func(res interface{}) interface{} {
+			return rangeEnd(res.(string))
+		})
+		stops := append([]parser{end}, others...)
 
-		// search until end symbol or end
-		out = searchUntil(expectString(r.EndSymbol))(in)
-		s := out.result.(search)
-		_, err = b.WriteString(s.consumed)
+		var b strings.Builder
+		_, err := b.WriteString(start.start)
 		check(err)
 
-		// if end symbol found, add to builder
-		if s.result != nil {
-			_, err = b.WriteString(r.EndSymbol)
+		// search until end symbol or end, skipping escapes and interpolations
+		var spans []removeRange
+		var spanStart, depth int
+		for done := false; !done; {
+			out = searchUntil(selectAny(stops))(in)
+			s := out.result.(search)
+			_, err = b.WriteString(s.consumed)
 			check(err)
+			in = out.remaining
+
+			switch res := s.result.(type) {
+			case nil:
+				done = true // reached end
+			case rangeEnd:
+				_, err = b.WriteString(string(res))
+				check(err)
+				if depth > 0 {
+					depth-- // closes a nested start symbol
+				} else {
+					done = true
+				}
+			case rangeLineEnd:
+				done = true // the newline is not part of the range
+			case rangeNestedStart:
+				_, err = b.WriteString(string(res))
+				check(err)
+				depth++
+			case escaped:
+				_, err = b.WriteString(string(res))
+				check(err)
+			case interpolationOutput:
+				// end the current span after the interpolation's start symbol
+				_, err = b.WriteString(res.start)
+				check(err)
+				spans = append(spans, removeRange{spanStart, b.Len() - spanStart, r.Color})
+
+				// nested ranges are relative to the start of the code
+				for _, nested := range res.spans {
+					nested.index += b.Len()
+					spans = append(spans, nested)
+				}
+				_, err = b.WriteString(res.code)
+				check(err)
+
+				// start a new span at the interpolation's end symbol
+				spanStart = b.Len()
+				_, err = b.WriteString(res.end)
+				check(err)
+			case rangeOutput:
+				// end the current span before the inner range
+				spans = append(spans, removeRange{spanStart, b.Len() - spanStart, r.Color})
+				for _, nested := range res.spans {
+					nested.index += b.Len()
+					spans = append(spans, nested)
+				}
+				_, err = b.WriteString(res.result)
+				check(err)
+
+				// start a new span after the inner range
+				spanStart = b.Len()
+			}
+		}
+		if !precedes(r.Precedes, in) {
+			return fail()
+		}
+		spans = append(spans, removeRange{spanStart, b.Len() - spanStart, r.Color})
+		return success(rangeOutput{b.String(), r, spans, nil}, in)
+	}
+}
+
+// Represents the start of a range and its corresponding end symbol.
+type rangeStart struct {
+	start    string
+	end      string
+	submatch string // first submatch of the start pattern
+}
+
+// Expects the start of a range, which is either its start symbol
+// or a match of its start pattern. If success, parser returns
+// a rangeStart, where the end symbol has any submatches expanded.
+func expectStart(r *style.Range) parser {
+	if r.StartPattern == nil {
+		return mapResult(expectString(r.StartSymbol), func(res interface{}) interface{} {
+			return rangeStart{r.StartSymbol, r.EndSymbol, ""}
+		})
+	}
+	return func(in parserInput) parserOutput {
+		rest := in.rest()
+		loc := r.StartPattern.FindStringSubmatchIndex(rest)
+		if loc == nil || loc[0] != 0 || loc[1] == 0 {
+			return fail()
+		}
+		end := r.StartPattern.ExpandString(nil, r.EndSymbol, rest, loc)
+		submatch := r.StartPattern.ExpandString(nil, "$1", rest, loc)
+		return success(rangeStart{rest[:loc[1]], string(end), string(submatch)}, in.advance(loc[1]))
+	}
+}
+
+// Parser for a pattern range, which is a single
+// anchored regex match of the range's Pattern.
+func expectPattern(r *style.Range) parser {
+	return func(in parserInput) parserOutput {
+		loc := r.Pattern.FindStringIndex(in.rest())
+		if loc == nil || loc[0] != 0 || loc[1] == 0 || !follows(r.Follows, in) {
+			return fail()
+		}
+		result := in.rest()[:loc[1]]
+		if !precedes(r.Precedes, in.advance(len(result))) {
+			return fail()
+		}
+		return success(rangeOutput{result, r, []removeRange{{0, len(result), r.Color}}, nil}, in.advance(len(result)))
+	}
+}
+
+// Parser for a range of embedded code, where the code between the symbols
+// is searched for the ranges of the embedded theme instead.
+// The parser returns a rangeOutput whose spans are the symbols
+// and the embedded ranges, and whose region is the code.
+// If the embedded language is unknown, the code is not highlighted.
+func expectEmbed(r *style.Range) parser {
+	startParser := expectStart(r)
+	var embedded parser
+	if r.Embed != nil {
+		embedded = getRangeParser(r.Embed.Ranges)
+	}
+	return func(in parserInput) parserOutput {
+		out := startParser(in)
+		if out.result == nil || !follows(r.Follows, in) {
+			return fail()
+		}
+		start := out.result.(rangeStart)
+
+		// look up the embedded language by name
+		theme, themeParser := r.Embed, embedded
+		if r.EmbedTheme != "" {
+			theme, themeParser = &style.Theme{}, getRangeParser(nil)
+			if l, ok := style.GetLanguage(start.submatch); ok {
+				if t, ok := l.Themes[r.EmbedTheme]; ok {
+					theme, themeParser = t, getRangeParser(t.Ranges)
+				}
+			}
+		}
+
+		// the code is an interpolation without a start symbol
+		out = expectInterpolation(&style.Interpolation{EndSymbol: start.end}, themeParser)(out.remaining)
+		code := out.result.(interpolationOutput)
+
+		spans := []removeRange{{0, len(start.start), r.Color}}
+		for _, nested := range code.spans {
+			nested.index += len(start.start)
+			spans = append(spans, nested)
+		}
+		codeEnd := len(start.start) + len(code.code)
+		spans = append(spans, removeRange{codeEnd, len(code.end), r.Color})
+		regions := []region{{len(start.start), len(code.code), theme}}
+		return success(rangeOutput{start.start + code.code + code.end, r, spans, regions}, out.remaining)
+	}
+}
+
+// Checks if the text before the input on the same line
+// matches a regex. A nil regex always matches.
+func follows(regex *regexp.Regexp, in parserInput) bool {
+	return regex == nil || regex.MatchString(in.line())
+}
+
+// Checks if the text after the input matches
+// a regex. A nil regex always matches.
+func precedes(regex *regexp.Regexp, in parserInput) bool {
+	return regex == nil || regex.MatchString(in.rest())
+}
+
+// Expects a newline without consuming it.
+// If success, parser returns a rangeLineEnd.
+func expectLineEnd() parser {
+	return func(in parserInput) parserOutput {
+		if out := expectRune(isRune('\n'))(in); out.result == nil {
+			return fail()
+		}
+		return success(rangeLineEnd{}, in)
+	}
+}
+
+// Expects an escape symbol followed by any rune (if not at the end).
+// If success, parser returns the escaped string.
+func expectEscape(escape string) parser {
+	return func(in parserInput) parserOutput {
+		out := expectString(escape)(in)
+		if out.result == nil {
+			return fail()
 		}
 		in = out.remaining
-		return success(rangeOutput{b.String(), r}, in)
+		if out = expectRune(anyRune())(in); out.result != nil {
+			return success(escaped(escape+string(out.result.(rune))), out.remaining)
+		}
+		return success(escaped(escape), in)
+	}
+}
+
+// Expects an interpolation, where the code between the start and end
+// symbols is searched for nested ranges using the inner parser.
+// If success, parser returns an interpolationOutput.
+func expectInterpolation(i *style.Interpolation, inner parser) parser {
+	return func(in parserInput) parserOutput {
+		out := expectString(i.StartSymbol)(in)
+		if out.result == nil {
+			return fail()
+		}
+		in = out.remaining
+
+		var code strings.Builder
+		var spans []removeRange
+		var depth int
+		for {
+			var consumed string
+			if out = expectString(i.EndSymbol)(in); out.result != nil {
+				// end symbol, unless it closes a nested open symbol
+				if depth == 0 {
+					return success(interpolationOutput{i.StartSymbol, code.String(), spans, i.EndSymbol}, out.remaining)
+				}
+				depth--
+				consumed = i.EndSymbol
+			} else if out = expectString(i.Open)(in); i.Open != "" && out.result != nil {
+				depth++
+				consumed = i.Open
+			} else if out = inner(in); out.result != nil {
+				// nested range
+				rOutput := out.result.(rangeOutput)
+				for _, nested := range rOutput.spans {
+					nested.index += code.Len()
+					spans = append(spans, nested)
+				}
+				consumed = rOutput.result
+			} else if out = expectRune(anyRune())(in); out.result != nil {
+				consumed = string(out.result.(rune))
+			} else {
+				// reached end
+				return success(interpolationOutput{i.StartSymbol, code.String(), spans, ""}, in)
+			}
+			_, err := code.WriteString(consumed)
+			check(err)
+			in = out.remaining
+		}
 	}
 }
 
@@ -2,14 +2,20 @@ package parser
 
 import (
 	"GDocs-Syntax-Highlighter/request"
+	"GDocs-Syntax-Highlighter/runner"
 	"GDocs-Syntax-Highlighter/style"
-	"regexp"
+	"sort"
 	"strings"
 	"unicode/utf8"
 
 	"google.golang.org/api/docs/v1"
 )
 
+var (
+	// the closing bracket of each opening bracket
+	closingBrackets = map[byte]rune{'(': ')', '[': ']', '{': '}'}
+)
+
 // Instance of parserInput for parsing a range.
 type rangeInput struct {
 	pos   int
@@ -20,6 +26,8 @@ type rangeInput struct {
 type rangeOutput struct {
 	result    string
 	rangeType *style.Range
+	spans     []removeRange // colored parts of the range, relative to its start
+	regions   []region      // embedded code in the range, relative to its start
 }
 
 // Gets the current rune and its size.
@@ -39,60 +47,103 @@ func (in rangeInput) advance(size int) parserInput {
 	return rangeInput{in.pos + size, in.runes}
 }
 
-// Remove a string of characters at a utf8 index.
+// Gets the runes that have not been parsed.
+func (in rangeInput) rest() string {
+	return in.runes[in.pos:]
+}
+
+// Gets the parsed runes on the current line.
+func (in rangeInput) line() string {
+	before := in.runes[:in.pos]
+	return before[strings.LastIndexByte(before, '\n')+1:]
+}
+
+// Remove a string of characters at a utf8 index,
+// highlighting them with a particular color.
 type removeRange struct {
 	index    int
 	utf8Size int
+	color    *docs.Color
+}
+
+// A region of embedded code at a utf8 index, which is
+// highlighted with the keywords of a particular theme.
+type region struct {
+	index    int
+	utf8Size int
+	theme    *style.Theme
+}
+
+// Gets the parser that selects any of the ranges, where the ranges
+// with a higher precedence are tried first, then in their order.
+// Ranges can be nested inside other ranges' interpolations.
+func getRangeParser(ranges []*style.Range) parser {
+	ranges = append([]*style.Range{}, ranges...)
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].Precedence > ranges[j].Precedence
+	})
+
+	var rangeParsers []parser
+	var anyRange parser
+	inner := func(in parserInput) parserOutput {
+		return anyRange(in)
+	}
+	for _, r := range ranges {
+		rangeParsers = append(rangeParsers, expectRange(r, inner))
+	}
+	anyRange = selectAny(rangeParsers)
+	return anyRange
 }
 
 // RemoveRanges removes the ranges from the instance's Code
 // string property and returns the list of requests to highlight them.
+// If the theme has a grammar, no ranges are removed and it returns
+// the list of requests to highlight the scopes of the grammar instead.
 func (c *CodeInstance) RemoveRanges(t *style.Theme) (reqs []*docs.Request) {
-	// create parser for each range
-	var rangeParsers []parser
-	for _, r := range t.Ranges {
-		rangeParsers = append(rangeParsers, expectRange(r))
+	if t.Grammar != nil {
+		c.regions = nil
+		return c.highlightGrammar(t)
 	}
+	rangeParser := getRangeParser(t.Ranges)
 
-	var removeRanges []removeRange      // ranges to be removed
-	utf16Offsets := make(map[int]int64) // add utf16 offset at certain utf8 indices in the sanitized string
-	var utf8Offset int                  // utf8 offset in sanitized string
-	var utf8MultiRangeStartIndex *int   // if multiple ranges in a row, utf8 start index in sanitized string of first range
+	var removeRanges []removeRange // ranges to be removed
+	var regions []region           // embedded code
 	in := rangeInput{runes: c.Code}
 	for r, size := in.current(); r != nil; r, size = in.current() {
-		out := selectAny(rangeParsers)(in)
+		out := rangeParser(in)
 		if out.result != nil {
-			// if range found, consume it and remove from string
+			// if range found, consume it and remove its spans from string
 			rOutput := out.result.(rangeOutput)
-			utf8StartIndex := in.pos
-			utf8Size := len(rOutput.result)
-			utf16Size := GetUtf16StringSize(rOutput.result)
-			removeRanges = append(removeRanges, removeRange{utf8StartIndex, utf8Size})
-
-			// update offset map to recreate utf8 -> utf16 map in sanitized string
-			if utf8MultiRangeStartIndex != nil {
-				// there is at least one range directly before this one
-				// so update the first range's utf8 -> utf16 index mapping in the sanitized string
-				utf16Offsets[*utf8MultiRangeStartIndex] = utf16Offsets[*utf8MultiRangeStartIndex] + utf16Size
-			} else {
-				// no range directly before this, so create new utf8 -> utf16 index mapping
-				utf8RangeStartIndex := utf8StartIndex + utf8Offset
-				utf16Offsets[utf8RangeStartIndex] = utf16Size
-				utf8MultiRangeStartIndex = &utf8RangeStartIndex
+			for _, span := range rOutput.spans {
+				if span.utf8Size > 0 {
+					span.index += in.pos
+					removeRanges = append(removeRanges, span)
+				}
+			}
+			for _, reg := range rOutput.regions {
+				reg.index += in.pos
+				regions = append(regions, reg)
 			}
-			utf8Offset -= utf8Size
-
-			// create request to update range's color using utf16 indices
-			utf16OffsetStartIndex := c.toUTF16[utf8StartIndex]
-			utf16Range := request.GetRange(utf16OffsetStartIndex, utf16OffsetStartIndex+utf16Size, "")
-			reqs = append(reqs, request.UpdateForegroundColor(rOutput.rangeType.Color, utf16Range))
-
 			in = out.remaining.(rangeInput)
 			continue
 		}
 		// failed to parse range, advance
 		in = in.advance(size).(rangeInput)
-		utf8MultiRangeStartIndex = nil
+	}
+
+	// move the regions to where they are in the sanitized string,
+	// where no removed range crosses the bounds of a region
+	c.regions = nil
+	for _, reg := range regions {
+		var removedBefore, removedInside int
+		for _, cur := range removeRanges {
+			if cur.index < reg.index {
+				removedBefore += cur.utf8Size
+			} else if cur.index < reg.index+reg.utf8Size {
+				removedInside += cur.utf8Size
+			}
+		}
+		c.regions = append(c.regions, region{reg.index - removedBefore, reg.utf8Size - removedInside, reg.theme})
 	}
 
 	if len(removeRanges) == 0 {
@@ -100,33 +151,58 @@ func (c *CodeInstance) RemoveRanges(t *style.Theme) (reqs []*docs.Request) {
 	}
 
 	var sanitized strings.Builder
-	var cur removeRange
+	var start int                  // utf8 index in Code after the previous removed range
+	toUTF16 := make(map[int]int64) // utf8 -> utf16 map of the sanitized string
+	keep := func(keepStart, keepEnd int) {
+		// the kept runes keep their utf16 indices
+		for i := range c.Code[keepStart:keepEnd] {
+			toUTF16[sanitized.Len()+i] = c.toUTF16[keepStart+i]
+		}
+		_, err := sanitized.WriteString(c.Code[keepStart:keepEnd])
+		check(err)
+	}
 
 	// remove ranges from Code
-	for len(removeRanges) > 0 {
-		start := cur.index + cur.utf8Size
-		cur, removeRanges = removeRanges[0], removeRanges[1:] // pop from slice
-		_, err := sanitized.WriteString(c.Code[start:cur.index])
-		check(err)
+	for _, cur := range removeRanges {
+		keep(start, cur.index)
+		start = cur.index + cur.utf8Size
+
+		// create requests to update range's color using utf16 indices
+		for _, utf16Range := range c.getUTF16Ranges(cur.index, start, "") {
+			reqs = append(reqs, request.UpdateForegroundColor(cur.color, utf16Range))
+		}
 	}
-	_, err := sanitized.WriteString(c.Code[cur.index+cur.utf8Size:])
-	check(err)
+	keep(start, len(c.Code))
 
-	// update Code (removed ranges)
+	// update Code (removed ranges) and its zero-based utf8 -> offset utf16 index mapping
 	c.Code = sanitized.String()
+	c.toUTF16 = toUTF16
+	return
+}
 
-	// update zero-based utf8 -> offset utf16 index mapping
-	utf16Index := *c.StartIndex
-	c.toUTF16 = make(map[int]int64)
-	var r rune
-	for i, utf8Width := 0, 0; i < len(c.Code); i += utf8Width {
-		if o, ok := utf16Offsets[i]; ok {
-			utf16Index += o // add utf16 offset for range removal
+// Gets the requests to highlight the tokens of the theme's grammar
+// with the colors of their scopes, where consecutive tokens
+// of the same color are highlighted together.
+func (c *CodeInstance) highlightGrammar(t *style.Theme) (reqs []*docs.Request) {
+	var start, end int
+	var color *docs.Color
+	highlight := func() {
+		if color != nil && end > start {
+			for _, utf16Range := range c.getUTF16Ranges(start, end, "") {
+				reqs = append(reqs, request.UpdateForegroundColor(color, utf16Range))
+			}
 		}
-		r, utf8Width = utf8.DecodeRuneInString(c.Code[i:])
-		c.toUTF16[i] = utf16Index
-		utf16Index += GetUtf16RuneSize(r)
 	}
+	for _, tok := range t.Grammar.Tokenize(c.Code) {
+		tokColor := t.GetScopeColor(tok.Scopes)
+		if tokColor == color && tok.Start == end {
+			end = tok.End
+			continue
+		}
+		highlight()
+		start, end, color = tok.Start, tok.End, tokColor
+	}
+	highlight()
 	return
 }
 
@@ -136,36 +212,189 @@ func (c *CodeInstance) Replace(s *style.Shortcut) (reqs []*docs.Request) {
 	for {
 		if res := s.Regex.FindStringSubmatchIndex(c.Code); res != nil {
 			utf8DelStart, utf8DelEnd := res[0], res[1]
-			utf16DelStart, utf16DelEnd := getUTF16SubstrIndices(c.Code[utf8DelStart:utf8DelEnd], c.Code, *c.StartIndex)
+			utf16DelStart, utf16DelEnd := c.getUTF16Indices(utf8DelStart, utf8DelEnd)
 
 			// delete target and insert replacement string
 			utf16DelRange := request.GetRange(utf16DelStart, utf16DelEnd, "")
 			reqs = append(reqs, request.Delete(utf16DelRange))
-			reqs = append(reqs, request.Insert(s.Replace, utf16DelStart))
+			reqs = append(reqs, request.Insert(s.Replace, utf16DelStart, ""))
 
 			// update end index for utf16 difference
 			utf16InsSize := GetUtf16StringSize(s.Replace)
 			newEndIndex := *c.EndIndex + utf16InsSize - (utf16DelEnd - utf16DelStart)
 			c.EndIndex = &newEndIndex
 
-			// replace c.Code
+			// replace c.Code, where the inserted lines have no line numbers or diff markers
+			line, lines := strings.Count(c.Code[:utf8DelStart], "\n"), strings.Count(c.Code[utf8DelStart:utf8DelEnd], "\n")
 			c.Code = c.Code[:utf8DelStart] + s.Replace + c.Code[utf8DelEnd:]
+			inserted := strings.Count(s.Replace, "\n")
+			c.lineNumbers = splicePrefixes(c.lineNumbers, line, lines, inserted)
+			c.diffMarkers = splicePrefixes(c.diffMarkers, line, lines, inserted)
 			continue
 		}
 		return
 	}
 }
 
-// Highlight gets the requests to highlight all matches of a regex with a particular color.
-func (c *CodeInstance) Highlight(r *regexp.Regexp, color *docs.Color, segmentID string) (reqs []*docs.Request) {
-	if results := r.FindAllStringSubmatchIndex(c.Code, -1); results != nil {
-		for _, res := range results {
-			utf8Start, utf8End := res[0], res[1]
-			utf16Size := GetUtf16StringSize(c.Code[utf8Start:utf8End])
-			utf16StartOffset := c.toUTF16[utf8Start]
-			utf16Range := request.GetRange(utf16StartOffset, utf16StartOffset+utf16Size, segmentID)
+// Gets the requests to highlight a match of a keyword at a utf8 index of Code,
+// where res are the submatch indices of the match. The keyword's color
+// highlights its group, then each of its groups is highlighted with its own color,
+// in the order of the groups so that nested groups win.
+func (c *CodeInstance) highlightMatch(k style.Keyword, res []int, offset int, segmentID string) (reqs []*docs.Request) {
+	highlight := func(group int, color *docs.Color) {
+		if color == nil || 2*group+1 >= len(res) || res[2*group] < 0 || res[2*group] == res[2*group+1] {
+			return
+		}
+		for _, utf16Range := range c.getUTF16Ranges(offset+res[2*group], offset+res[2*group+1], segmentID) {
 			reqs = append(reqs, request.UpdateForegroundColor(color, utf16Range))
 		}
 	}
+
+	highlight(k.Group, k.Color)
+	var groups []int
+	for group := range k.Groups {
+		groups = append(groups, group)
+	}
+	sort.Ints(groups)
+	for _, group := range groups {
+		highlight(group, k.Groups[group])
+	}
+	return
+}
+
+// HighlightDiagnostics gets the requests to highlight the code of each diagnostic
+// with the theme's error or warning highlight color. It must be called before
+// any ranges are removed, since the diagnostics refer to the lines and columns
+// of the whole code. Empty diagnostics highlight the rune they start at.
+func (c *CodeInstance) HighlightDiagnostics(diagnostics []runner.Diagnostic, t *style.Theme) (reqs []*docs.Request) {
+	for _, d := range diagnostics {
+		utf8Start, utf8End, ok := c.getDiagnosticIndices(d)
+		if !ok {
+			continue
+		}
+
+		color := t.WarningHighlight
+		if d.IsError() {
+			color = t.ErrorHighlight
+		}
+		for _, utf16Range := range c.getUTF16Ranges(utf8Start, utf8End, "") {
+			reqs = append(reqs, request.UpdateHighlightColor(color, utf16Range))
+		}
+	}
+	return
+}
+
+// GetAnchor gets the anchor of a Drive comment to the code of the first diagnostic
+// that is not past the end of the code, which quotes the lines of its code,
+// or nil if there is none. Unlike highlighting, it can be used before the code
+// is mapped to utf16, but not after any ranges are removed. After the line numbers
+// are updated, the anchor is in the document after the update, so the comment
+// must be posted after it.
+func (c *CodeInstance) GetAnchor(diagnostics []runner.Diagnostic) *request.Anchor {
+	for _, d := range diagnostics {
+		utf8Start, utf8End, ok := c.getDiagnosticIndices(d)
+		if !ok {
+			continue
+		}
+		utf16Start, utf16End := c.getUTF16Indices(utf8Start, utf8End)
+		_, length := c.getUTF16Indices(0, len(c.Code))
+
+		// quote the lines of the code, without the newline of the last line
+		lineStart := strings.LastIndexByte(c.Code[:utf8Start], '\n') + 1
+		lineEnd := len(c.Code)
+		if i := strings.IndexByte(c.Code[utf8End-1:], '\n'); i >= 0 {
+			lineEnd = utf8End - 1 + i
+		}
+		return &request.Anchor{
+			Range:  request.GetRange(utf16Start, utf16End, ""),
+			Length: length,
+			Quote:  c.Code[lineStart:lineEnd],
+		}
+	}
+	return nil
+}
+
+// Gets the utf8 indices in Code of the code of a diagnostic, where an empty
+// diagnostic has the rune it starts at. It is not ok past the end of the code.
+func (c *CodeInstance) getDiagnosticIndices(d runner.Diagnostic) (utf8Start, utf8End int, ok bool) {
+	utf8Start = runner.GetUTF8Index(c.Code, d.Line, d.Column)
+	utf8End = runner.GetUTF8Index(c.Code, d.EndLine, d.EndColumn)
+	if utf8End <= utf8Start {
+		if utf8Start == len(c.Code) {
+			return 0, 0, false
+		}
+		_, size := utf8.DecodeRuneInString(c.Code[utf8Start:])
+		utf8End = utf8Start + size
+	}
+	return utf8Start, utf8End, true
+}
+
+// HighlightKeywords gets the requests to highlight the keywords of a theme,
+// where embedded code is highlighted with the keywords of its own theme instead.
+// It must be called after the ranges are removed.
+func (c *CodeInstance) HighlightKeywords(t *style.Theme) (reqs []*docs.Request) {
+	var start int
+	for _, reg := range c.regions {
+		reqs = append(reqs, c.highlightKeywords(t.Keywords, start, reg.index)...)
+		reqs = append(reqs, c.highlightKeywords(reg.theme.Keywords, reg.index, reg.index+reg.utf8Size)...)
+		start = reg.index + reg.utf8Size
+	}
+	return append(reqs, c.highlightKeywords(t.Keywords, start, len(c.Code))...)
+}
+
+// Gets the requests to highlight keywords between utf8 indices of Code.
+func (c *CodeInstance) highlightKeywords(keywords []style.Keyword, start, end int) (reqs []*docs.Request) {
+	code := c.Code[start:end]
+	for _, k := range keywords {
+		for _, res := range k.Regex.FindAllStringSubmatchIndex(code, -1) {
+			reqs = append(reqs, c.highlightMatch(k, res, start, "")...)
+		}
+	}
+	return
+}
+
+// HighlightBrackets gets the requests to color the brackets of the code
+// by their depth with the theme's bracket colors, where unmatched brackets
+// are colored with the theme's bracket error color. It must be called after
+// the ranges are removed, so that brackets in strings and comments are ignored.
+// Since no ranges are removed from code highlighted by a grammar, its brackets
+// are not colored.
+func (c *CodeInstance) HighlightBrackets(t *style.Theme) (reqs []*docs.Request) {
+	if t.Grammar != nil || len(t.BracketColors) == 0 {
+		return
+	}
+
+	type bracket struct {
+		index int // utf8 index in Code
+		color *docs.Color
+	}
+	var brackets []bracket
+	var open []int // indices in brackets of the brackets that are not closed
+	for i, r := range c.Code {
+		switch r {
+		case '(', '[', '{':
+			brackets = append(brackets, bracket{i, t.BracketColors[len(open)%len(t.BracketColors)]})
+			open = append(open, len(brackets)-1)
+		case ')', ']', '}':
+			if len(open) > 0 && closingBrackets[c.Code[brackets[open[len(open)-1]].index]] == r {
+				// same color as its opening bracket
+				brackets = append(brackets, bracket{i, brackets[open[len(open)-1]].color})
+				open = open[:len(open)-1]
+			} else {
+				brackets = append(brackets, bracket{i, t.BracketError})
+			}
+		}
+	}
+	for _, i := range open {
+		brackets[i].color = t.BracketError
+	}
+
+	for _, b := range brackets {
+		if b.color == nil {
+			continue
+		}
+		utf16Start := c.toUTF16[b.index]
+		reqs = append(reqs, request.UpdateForegroundColor(b.color, request.GetRange(utf16Start, utf16Start+1, "")))
+	}
 	return
 }
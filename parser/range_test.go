@@ -0,0 +1,288 @@
+package parser
+
+import (
+	"GDocs-Syntax-Highlighter/runner"
+	"GDocs-Syntax-Highlighter/style"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// Gets the utf16 range and the highlight color of each request
+// that highlights the background of text.
+func getHighlights(reqs []*docs.Request) (ranges [][2]int64, colors []*docs.Color) {
+	for _, req := range reqs {
+		if u := req.UpdateTextStyle; u != nil && u.TextStyle.BackgroundColor != nil {
+			ranges = append(ranges, [2]int64{u.Range.StartIndex, u.Range.EndIndex})
+			colors = append(colors, u.TextStyle.BackgroundColor.Color)
+		}
+	}
+	return
+}
+
+func TestHighlightDiagnostics(t *testing.T) {
+	// the emoji is two utf16 code units
+	c := newTestInstance(t, "bash", "echo 😀 $a\necho\n")
+	th := c.GetTheme()
+	diagnostics := []runner.Diagnostic{
+		{Line: 1, Column: 8, EndLine: 1, EndColumn: 10, Severity: "warning"},
+		{Line: 2, Column: 1, EndLine: 2, EndColumn: 1, Severity: "error"}, // empty
+		{Line: 3, Column: 1, EndLine: 3, EndColumn: 1, Severity: "error"}, // past the end
+	}
+	ranges, colors := getHighlights(c.HighlightDiagnostics(diagnostics, th))
+
+	wantRanges := [][2]int64{{9, 11}, {12, 13}}
+	wantColors := []*docs.Color{th.WarningHighlight, th.ErrorHighlight}
+	if len(ranges) != len(wantRanges) {
+		t.Fatalf("highlighted ranges = %v, want %v", ranges, wantRanges)
+	}
+	for i := range ranges {
+		if ranges[i] != wantRanges[i] || colors[i] != wantColors[i] {
+			t.Errorf("highlight %d = %v %s, want %v %s", i, ranges[i], colorName(colors[i]), wantRanges[i], colorName(wantColors[i]))
+		}
+	}
+}
+
+func TestGetAnchor(t *testing.T) {
+	c := newTestInstance(t, "bash", "echo 😀 $a\necho\n")
+	diagnostics := []runner.Diagnostic{
+		{Line: 3, Column: 1, EndLine: 3, EndColumn: 1, Severity: "error"}, // past the end
+		{Line: 1, Column: 8, EndLine: 1, EndColumn: 10, Severity: "warning"},
+	}
+	a := c.GetAnchor(diagnostics)
+	if a == nil {
+		t.Fatal("GetAnchor() = nil")
+	}
+	if a.Range.StartIndex != 9 || a.Range.EndIndex != 11 || a.Length != *c.EndIndex || a.Quote != "echo 😀 $a" {
+		t.Errorf("GetAnchor() = %v %d %q, want [9 11] %d %q", a.Range, a.Length, a.Quote, *c.EndIndex, "echo 😀 $a")
+	}
+	if a := c.GetAnchor(diagnostics[:1]); a != nil {
+		t.Errorf("GetAnchor() past the end = %v, want nil", a)
+	}
+}
+
+func TestGetAnchorAfterFormat(t *testing.T) {
+	// a format that adds a line, then a run that fails on the added line
+	doc := "1 │ echo a;  false\n"
+	c := newDocInstance(t, "bash", doc)
+	c.LineNumbers = true
+	c.Code = "echo a\nfalse"
+	reqs := c.UpdateCode()
+	c.MapToUTF16()
+	reqs = append(reqs, c.UpdateLineNumbers(c.GetTheme())...)
+	a := c.GetAnchor([]runner.Diagnostic{{Line: 2, Column: 1, EndLine: 2, EndColumn: 6, Severity: "error"}})
+	if a == nil {
+		t.Fatal("GetAnchor() = nil")
+	}
+
+	// the anchor is in the document after the update
+	doc = applyTextRequests(doc, reqs)
+	if want := "1 │ echo a\n2 │ false\n"; doc != want {
+		t.Fatalf("document = %q, want %q", doc, want)
+	}
+	runes := []rune(doc)
+	if got := string(runes[a.Range.StartIndex-1 : a.Range.EndIndex-1]); got != "false" {
+		t.Errorf("anchored code = %q, want %q", got, "false")
+	}
+	if want := int64(len(runes)); a.Length != want || a.Quote != "false" {
+		t.Errorf("GetAnchor() = %d %q, want %d %q", a.Length, a.Quote, want, "false")
+	}
+}
+
+func TestHighlightKeywordGroups(t *testing.T) {
+	red, blue := style.DarkThemeLightRedOrange, style.DarkThemeDarkBlue
+	code := "func name(x) func (r T) m() type T x"
+	tests := []struct {
+		name    string
+		keyword style.Keyword
+		want    []colored
+	}{
+		{"whole match", style.Keyword{Regex: regexp.MustCompile("type \\w+"), Color: red}, []colored{
+			{"type T", red},
+		}},
+		{"group", style.Keyword{Regex: regexp.MustCompile("func\\s*(?:\\([^)]*\\)\\s*)?(\\w+)\\("), Color: red, Group: 1}, []colored{
+			{"func ", nil}, {"name", red}, {"(x) func (r T) ", nil}, {"m", red}, {"(", nil},
+		}},
+		// nested groups win over their outer groups
+		{"groups", style.Keyword{Regex: regexp.MustCompile("(type) ((\\w+) x)"), Groups: map[int]*docs.Color{3: red, 1: blue, 2: blue}}, []colored{
+			{"type", blue}, {" ", nil}, {"T", red}, {" x", blue},
+		}},
+		{"color and groups", style.Keyword{Regex: regexp.MustCompile("type (\\w+)"), Color: blue, Groups: map[int]*docs.Color{1: red}}, []colored{
+			{"type ", blue}, {"T", red},
+		}},
+		// a group that does not participate is not highlighted
+		{"optional group", style.Keyword{Regex: regexp.MustCompile("(r )?T"), Groups: map[int]*docs.Color{1: red}}, []colored{
+			{"r ", red}, {"T", nil}, {"T", nil},
+		}},
+		{"missing group", style.Keyword{Regex: regexp.MustCompile("type"), Color: red, Group: 2}, []colored{
+			{"type", nil},
+		}},
+	}
+	for _, tt := range tests {
+		c := newTestInstance(t, "text", code)
+		theme := &style.Theme{Keywords: []style.Keyword{tt.keyword}}
+		colors := applyForegroundColors(code, c.HighlightKeywords(theme))
+		var pos int
+		for _, w := range tt.want {
+			i := pos + strings.Index(code[pos:], w.text)
+			for j := i; j < i+len(w.text); j++ {
+				if colors[j] != w.color {
+					t.Errorf("%s: `%s` at %d has color %s, want %s", tt.name, w.text, i, colorName(colors[j]), colorName(w.color))
+					break
+				}
+			}
+			pos = i + len(w.text)
+		}
+	}
+}
+
+// Gets the foreground color of each byte of ASCII code
+// highlighted by ranges, and the code after removing them.
+func getRangeColors(t *testing.T, code string, ranges []*style.Range) ([]*docs.Color, string) {
+	c := newTestInstance(t, "text", code)
+	reqs := c.RemoveRanges(&style.Theme{Ranges: ranges})
+	return applyForegroundColors(code, reqs), c.Code
+}
+
+func TestRemoveRanges(t *testing.T) {
+	str, comment, inner := style.DarkThemeLightRedOrange, style.DarkThemeDarkGreen, style.DarkThemeStrawYellow
+	tests := []struct {
+		name   string
+		ranges []*style.Range
+		code   string
+		want   []colored
+		rest   string // the code after removing the ranges
+	}{
+		{"symbols", []*style.Range{{StartSymbol: "\"", EndSymbol: "\"", Color: str}}, `a "b" c`, []colored{
+			{"a ", nil}, {`"b"`, str}, {" c", nil},
+		}, "a  c"},
+		{"escape", []*style.Range{{StartSymbol: "\"", EndSymbol: "\"", Color: str, Escape: "\\"}}, `"a\"b\\" c`, []colored{
+			{`"a\"b\\"`, str}, {" c", nil},
+		}, " c"},
+		{"no escape", []*style.Range{{StartSymbol: "\"", EndSymbol: "\"", Color: str}}, `"a\"b" c`, []colored{
+			{`"a\"`, str}, {"b", nil}, {`" c`, str},
+		}, "b"},
+		{"nested", []*style.Range{{StartSymbol: "/*", EndSymbol: "*/", Color: comment, Nested: true}}, "/* a /* b */ c */ d", []colored{
+			{"/* a /* b */ c */", comment}, {" d", nil},
+		}, " d"},
+		{"not nested", []*style.Range{{StartSymbol: "/*", EndSymbol: "*/", Color: comment}}, "/* a /* b */ c */ d", []colored{
+			{"/* a /* b */", comment}, {" c */ d", nil},
+		}, " c */ d"},
+		// an unterminated single line range ends before its newline
+		{"single line", []*style.Range{{StartSymbol: "'", EndSymbol: "'", Color: str, Escape: "\\", SingleLine: true}}, "'a\nb 'c\\\nd'", []colored{
+			{"'a", str}, {"\nb ", nil}, {"'c\\\nd'", str},
+		}, "\nb "},
+		{"unterminated", []*style.Range{{StartSymbol: "\"", EndSymbol: "\"", Color: str}}, "a \"b\nc", []colored{
+			{"a ", nil}, {"\"b\nc", str},
+		}, "a "},
+		// ranges starting at the same rune
+		{"first wins", []*style.Range{
+			{StartSymbol: "#", EndSymbol: "\n", Color: comment},
+			{StartSymbol: "#{", EndSymbol: "}", Color: str},
+		}, "#{a} b\n", []colored{{"#{a} b\n", comment}}, ""},
+		{"precedence wins", []*style.Range{
+			{StartSymbol: "#", EndSymbol: "\n", Color: comment},
+			{StartSymbol: "#{", EndSymbol: "}", Color: str, Precedence: 1},
+		}, "#{a} b\n", []colored{{"#{a}", str}, {" b", nil}}, " b\n"},
+		// a range hides ranges that start inside of it
+		{"hidden", []*style.Range{
+			{StartSymbol: "\"", EndSymbol: "\"", Color: str, Precedence: 1},
+			{StartSymbol: "//", EndSymbol: "\n", Color: comment},
+		}, "// \"a\n\"b // c\"\n", []colored{{"// \"a\n", comment}, {"\"b // c\"", str}}, "\n"},
+		{"interpolation", []*style.Range{{StartSymbol: "`", EndSymbol: "`", Color: str,
+			Interpolations: []*style.Interpolation{{StartSymbol: "${", EndSymbol: "}", Open: "{"}}},
+		}, "`a ${ {b: `c`} } d`", []colored{
+			{"`a ${", str}, {" {b: ", nil}, {"`c`", str}, {"} ", nil}, {"} d`", str},
+		}, " {b: } "},
+		{"inner", []*style.Range{{StartSymbol: "\"", EndSymbol: "\"", Color: str, Inner: []*style.Range{{Pattern: regexp.MustCompile("^\\$\\w+"), Color: inner}}}},
+			"\"a $b c\"", []colored{{"\"a ", str}, {"$b", inner}, {" c\"", str}}, ""},
+		{"start pattern", []*style.Range{{StartPattern: regexp.MustCompile("^<<(\\w+)\n"), EndSymbol: "\n$1\n", Color: str}},
+			"<<END\na\nEND\nb", []colored{{"<<END\na\nEND\n", str}, {"b", nil}}, "b"},
+		{"follows and precedes", []*style.Range{{Pattern: regexp.MustCompile("^\\w+"), Color: str,
+			Follows: regexp.MustCompile("(^|,)\\s*$"), Precedes: regexp.MustCompile("^:")}},
+			"a: b c, d:", []colored{{"a", str}, {": b c, ", nil}, {"d", str}}, ": b c, :"},
+	}
+	for _, tt := range tests {
+		colors, rest := getRangeColors(t, tt.code, tt.ranges)
+		var pos int
+		for _, w := range tt.want {
+			i := strings.Index(tt.code[pos:], w.text)
+			if i < 0 {
+				t.Errorf("%s: `%s` not found in the code after index %d", tt.name, w.text, pos)
+				continue
+			}
+			i += pos
+			for j := i; j < i+len(w.text); j++ {
+				if colors[j] != w.color {
+					t.Errorf("%s: `%s` at %d has color %s at %q, want %s", tt.name, w.text, i, colorName(colors[j]), tt.code[j], colorName(w.color))
+					break
+				}
+			}
+			pos = i + len(w.text)
+		}
+		if rest != tt.rest {
+			t.Errorf("%s: code after removing the ranges = %q, want %q", tt.name, rest, tt.rest)
+		}
+	}
+}
+
+func TestRemoveRangesWithGutter(t *testing.T) {
+	// the line numbers inside a removed range are not colored with it
+	doc := "1 │ x /* a\n2 │ b */ y"
+	c := newDocInstance(t, "go", doc)
+	var colored []string
+	runes := []rune(doc)
+	for _, req := range c.RemoveRanges(c.GetTheme()) {
+		if u := req.UpdateTextStyle; u != nil && u.TextStyle.ForegroundColor != nil {
+			colored = append(colored, string(runes[u.Range.StartIndex-1:u.Range.EndIndex-1]))
+		}
+	}
+	if want := []string{"/* a\n", "b */"}; !reflect.DeepEqual(colored, want) {
+		t.Errorf("colored ranges = %q, want %q", colored, want)
+	}
+}
+
+func TestHighlightBrackets(t *testing.T) {
+	// the emoji is two utf16 code units, and brackets in strings and comments are ignored
+	code := "f(\"(\", a[{😀}]) // )\n(]\n{ ("
+	c := newTestInstance(t, "go", code)
+	th := c.GetTheme()
+	c.RemoveRanges(th)
+
+	var ranges [][2]int64
+	var colors []*docs.Color
+	for _, req := range c.HighlightBrackets(th) {
+		u := req.UpdateTextStyle
+		ranges = append(ranges, [2]int64{u.Range.StartIndex, u.Range.EndIndex})
+		colors = append(colors, u.TextStyle.ForegroundColor.Color)
+	}
+
+	first, second, third, bad := th.BracketColors[0], th.BracketColors[1], th.BracketColors[2], th.BracketError
+	wantRanges := [][2]int64{{2, 3}, {9, 10}, {10, 11}, {13, 14}, {14, 15}, {15, 16}, {22, 23}, {23, 24}, {25, 26}, {27, 28}}
+	wantColors := []*docs.Color{first, second, third, third, second, first, bad, bad, bad, bad}
+	if !reflect.DeepEqual(ranges, wantRanges) {
+		t.Fatalf("bracket ranges = %v, want %v", ranges, wantRanges)
+	}
+	for i := range colors {
+		if colors[i] != wantColors[i] {
+			t.Errorf("bracket %d color = %s, want %s", i, colorName(colors[i]), colorName(wantColors[i]))
+		}
+	}
+}
+
+func TestHighlightBracketsCycle(t *testing.T) {
+	c := newTestInstance(t, "text", "((((a))))")
+	th := c.GetTheme()
+	c.RemoveRanges(th)
+	colors := applyForegroundColors(c.Code, c.HighlightBrackets(th))
+	// the colors cycle by depth
+	want := []*docs.Color{th.BracketColors[0], th.BracketColors[1], th.BracketColors[2], th.BracketColors[0], nil}
+	for i, w := range want {
+		if colors[i] != w || colors[len(colors)-1-i] != w {
+			t.Errorf("brackets at depth %d have colors %s and %s, want %s", i, colorName(colors[i]), colorName(colors[len(colors)-1-i]), colorName(w))
+		}
+	}
+}
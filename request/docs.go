@@ -84,13 +84,14 @@ func UpdateBackgroundColor(c *docs.Color, r *docs.Range) *docs.Request {
 	}
 }
 
-// Insert inserts text at an index.
-func Insert(text string, start int64) *docs.Request {
+// Insert inserts text at an index of a segment (empty for the body).
+func Insert(text string, start int64, segmentID string) *docs.Request {
 	return &docs.Request{
 		InsertText: &docs.InsertTextRequest{
 			Text: text,
 			Location: &docs.Location{
-				Index: start,
+				Index:     start,
+				SegmentId: segmentID,
 			},
 		},
 	}
@@ -1,15 +1,87 @@
 package request
 
-import "google.golang.org/api/drive/v3"
+import (
+	"encoding/json"
+
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/drive/v3"
+)
 
 const (
-	content = "content"
+	content           = "content"
+	anchor            = "anchor"
+	quotedFileContent = "quotedFileContent"
+	plainText         = "text/plain"
+
+	// the revision of an anchor that is the latest revision, since
+	// the revision IDs of the Docs API are not those of the Drive API,
+	// which is the document after the update if the comment is posted after it
+	headRevision = "head"
 )
 
+// Anchor describes the code that a Drive comment is about,
+// which is a range of the latest revision of a document's body.
+// The range includes any line numbers and diff markers inside it,
+// since they are text of the body.
+type Anchor struct {
+	Range  *docs.Range // range of the code in the body, whose indices start at 1
+	Length int64       // length of the body (its end index)
+	Quote  string      // quoted code, such as the lines of the range
+}
+
+// The anchor of a Drive comment, which is a JSON string of the regions
+// of a revision of the file that the comment is about.
+type commentAnchor struct {
+	Revision string         `json:"r"`
+	Regions  []anchorRegion `json:"a"`
+}
+
+// A region of a comment's anchor, which is a range of the text of a document.
+type anchorRegion struct {
+	Text anchorText `json:"txt"`
+}
+
+// A range of the plain text of a document, whose offsets start at 0,
+// where the max length is the text's length.
+type anchorText struct {
+	Offset    int64 `json:"o"`
+	Length    int64 `json:"l"`
+	MaxLength int64 `json:"ml"`
+}
+
+// Gets the JSON string of an anchor. Drive does not document anchors of
+// Google Docs, so the offsets are those of the body as plain text,
+// which are the body's indices minus 1 when it only has text.
+func (a *Anchor) String() string {
+	b, err := json.Marshal(commentAnchor{
+		Revision: headRevision,
+		Regions: []anchorRegion{{anchorText{
+			Offset:    a.Range.StartIndex - 1,
+			Length:    a.Range.EndIndex - a.Range.StartIndex,
+			MaxLength: a.Length - 1,
+		}}},
+	})
+	if err != nil {
+		panic(err) // can not fail
+	}
+	return string(b)
+}
+
 // CreateComment gets the *drive.CommentsCreateCall used to create
-// a new Google Drive comment.
-func CreateComment(comment, docID string, c *drive.CommentsService) *drive.CommentsCreateCall {
+// a new Google Drive comment. If the anchor is not nil, the comment
+// is anchored to its code and quotes it.
+func CreateComment(comment string, a *Anchor, docID string, c *drive.CommentsService) *drive.CommentsCreateCall {
+	if a == nil {
+		return c.Create(docID, &drive.Comment{
+			Content: comment,
+		}).Fields(content)
+	}
 	return c.Create(docID, &drive.Comment{
 		Content: comment,
-	}).Fields(content)
+		Anchor:  a.String(),
+		QuotedFileContent: &drive.CommentQuotedFileContent{
+			MimeType: plainText,
+			Value:    a.Quote,
+		},
+	}).Fields(content, anchor, quotedFileContent)
 }
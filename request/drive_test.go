@@ -0,0 +1,14 @@
+package request
+
+import (
+	"testing"
+)
+
+func TestAnchorString(t *testing.T) {
+	a := &Anchor{Range: GetRange(9, 11, ""), Length: 17, Quote: "echo $a"}
+	// the offsets of the text start at 0, unlike the indices of the body
+	want := `{"r":"head","a":[{"txt":{"o":8,"l":2,"ml":16}}]}`
+	if got := a.String(); got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+)
+
+const (
+	bashFile = "main.sh" // file name for running Bash
+)
+
+// A shellcheck comment in the `json1` format,
+// where columns count tabs as a single rune.
+type shellCheckComment struct {
+	Line      int    `json:"line"`
+	EndLine   int    `json:"endLine"`
+	Column    int    `json:"column"`
+	EndColumn int    `json:"endColumn"`
+	Level     string `json:"level"`
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+}
+
+// A shellcheck result in the `json1` format.
+type shellCheckResult struct {
+	Comments []shellCheckComment `json:"comments"`
+}
+
+// FormatBash runs `shfmt` on a Bash program as a string
+// and returns the formatted result as well as an error containing
+// the command's STDERR if the command exited with a non-zero code.
+func FormatBash(text string, opts Options) (string, error) {
+	shfmt, err := lookPath("shfmt")
+	if err != nil {
+		return "", err
+	}
+	return formatLocal(text, shfmt, "-ln", "bash")
+}
+
+// RunBash runs Bash using a local `bash` under resource limits.
+func RunBash(program string, opts Options) (*RunResult, error) {
+	bash, err := lookPath("bash")
+	if err != nil {
+		return nil, err
+	}
+	return withTempFile(bashFile, program, func(dir string) (*RunResult, error) {
+		return runLimited(dir, bash, bashFile)
+	})
+}
+
+// LintBash runs `shellcheck` on a Bash program as a string
+// and returns its diagnostics, or an error if shellcheck failed.
+func LintBash(program string, opts Options) ([]Diagnostic, error) {
+	shellCheck, err := lookPath("shellcheck")
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(shellCheck, "--format=json1", "--shell=bash", "-")
+	var stdOut, stdErr bytes.Buffer
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewBufferString(program), &stdOut, &stdErr
+
+	// shellcheck exits with 1 if there are any comments
+	err = cmd.Run()
+	if e, ok := err.(*exec.ExitError); ok {
+		if e.ExitCode() != 1 {
+			return nil, fmt.Errorf("%v - %s", err, stdErr.String())
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to run `%s`: %v", cmd, err)
+	}
+
+	var res shellCheckResult
+	if err = json.Unmarshal(stdOut.Bytes(), &res); err != nil {
+		return nil, fmt.Errorf("failed to parse shellcheck output: %v", err)
+	}
+	var diagnostics []Diagnostic
+	for _, c := range res.Comments {
+		diagnostics = append(diagnostics, Diagnostic{
+			Line:      c.Line,
+			Column:    c.Column,
+			EndLine:   c.EndLine,
+			EndColumn: c.EndColumn,
+			Severity:  c.Level,
+			Message:   fmt.Sprintf("%s (SC%d)", c.Message, c.Code),
+		})
+	}
+	return diagnostics, nil
+}
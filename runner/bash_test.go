@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatBash(t *testing.T) {
+	requireTool(t, "shfmt")
+	formatted := checkIdempotent(t, FormatBash, "if true;then\necho hi\nfi\n", Options{})
+	if formatted != "if true; then\n\techo hi\nfi\n" {
+		t.Errorf("FormatBash() = %q", formatted)
+	}
+}
+
+func TestRunBash(t *testing.T) {
+	requireTool(t, "bash")
+	res, err := RunBash("echo \"$((1 + 2))\"\nexit 5\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "3\n" || res.Status != 5 {
+		t.Errorf("RunBash() = %+v, want output 3 and status 5", res)
+	}
+}
+
+func TestLintBash(t *testing.T) {
+	requireTool(t, "shellcheck")
+	diagnostics, err := LintBash("#!/bin/bash\necho $1\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 1 {
+		t.Fatalf("LintBash() = %v, want one diagnostic", diagnostics)
+	}
+	d := diagnostics[0]
+	if d.Line != 2 || d.Column != 6 || d.EndLine != 2 || d.EndColumn != 8 || d.Severity != "info" {
+		t.Errorf("LintBash() = %+v, want the unquoted `$1`", d)
+	}
+
+	diagnostics, err = LintBash("#!/bin/bash\necho \"$1\"\n", Options{})
+	if err != nil || len(diagnostics) != 0 {
+		t.Errorf("LintBash() = %v, %v, want no diagnostics", diagnostics, err)
+	}
+}
+
+func TestFormatBashArgs(t *testing.T) {
+	defer fakeTools(t, map[string]string{"shfmt": `echo "$@"; cat`})()
+	formatted, err := FormatBash("echo hi\n", Options{})
+	if want := "-ln bash\necho hi\n"; err != nil || formatted != want {
+		t.Errorf("FormatBash() = %q, %v, want %q", formatted, err, want)
+	}
+}
+
+func TestFormatBashError(t *testing.T) {
+	defer fakeTools(t, map[string]string{"shfmt": "echo '<standard input>:1:4: reached EOF without closing quote' >&2; exit 1"})()
+	program := "a='\n"
+	_, err := FormatBash(program, Options{})
+	if err == nil || !strings.Contains(err.Error(), "exit status 1 - <standard input>:1:4: reached EOF") {
+		t.Fatalf("FormatBash() error = %v, want the STDERR of shfmt", err)
+	}
+	want := []Diagnostic{{Line: 1, Column: 4, EndLine: 1, EndColumn: 4, Severity: "error", Message: "reached EOF without closing quote"}}
+	if got := ParseDiagnostics(program, err.Error()); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDiagnostics() = %v, want %v", got, want)
+	}
+}
+
+func TestRunBashArgs(t *testing.T) {
+	defer fakeTools(t, map[string]string{"bash": `echo "$@"; cat "$1"; exit 5`})()
+	res, err := RunBash("echo hi\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "main.sh\necho hi\n" || res.Status != 5 {
+		t.Errorf("RunBash() = %+v, want the program file run with status 5", res)
+	}
+}
+
+func TestLintBashOutput(t *testing.T) {
+	// shellcheck exits with 1 if there are any comments
+	output := `{"comments":[{"line":2,"endLine":2,"column":6,"endColumn":8,"level":"info","code":2086,"message":"Double quote to prevent globbing."}]}`
+	defer fakeTools(t, map[string]string{"shellcheck": `[ "$*" = "--format=json1 --shell=bash -" ] || exit 3; echo '` + output + `'; exit 1`})()
+	diagnostics, err := LintBash("#!/bin/bash\necho $1\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Diagnostic{{Line: 2, Column: 6, EndLine: 2, EndColumn: 8, Severity: "info", Message: "Double quote to prevent globbing. (SC2086)"}}
+	if !reflect.DeepEqual(diagnostics, want) {
+		t.Errorf("LintBash() = %v, want %v", diagnostics, want)
+	}
+}
+
+func TestLintBashError(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"failure", "echo 'bad option' >&2; exit 3", "exit status 3 - bad option"},
+		{"invalid output", "echo 'not json'", "failed to parse shellcheck output"},
+	}
+	for _, tt := range tests {
+		restore := fakeTools(t, map[string]string{"shellcheck": tt.script})
+		_, err := LintBash("echo\n", Options{})
+		restore()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: LintBash() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package runner
+
+const (
+	cFile   = "main.c"   // file name for compiling C
+	cppFile = "main.cpp" // file name for compiling C++
+	binFile = "./main"   // path of the compiled binary
+)
+
+// FormatC runs `clang-format` on a C program as a string
+// and returns the formatted result as well as an error containing
+// the command's STDERR if the command exited with a non-zero code.
+func FormatC(text string, opts Options) (string, error) {
+	return formatClang(text, cFile, opts.Style)
+}
+
+// FormatCpp runs `clang-format` on a C++ program as a string
+// and returns the formatted result as well as an error containing
+// the command's STDERR if the command exited with a non-zero code.
+func FormatCpp(text string, opts Options) (string, error) {
+	return formatClang(text, cppFile, opts.Style)
+}
+
+// Runs a locally installed `clang-format` with a particular style,
+// which infers the language from the extension of the file path.
+func formatClang(text, file, style string) (string, error) {
+	clangFormat, err := lookPath("clang-format")
+	if err != nil {
+		return "", err
+	}
+	args := []string{"--assume-filename=" + file}
+	if style != "" {
+		args = append(args, "--style="+style)
+	}
+	return formatLocal(text, clangFormat, args...)
+}
+
+// RunC compiles C using a local `gcc` (or `clang`),
+// then runs the binary under resource limits.
+func RunC(program string, opts Options) (*RunResult, error) {
+	return compileAndRun(program, cFile, []string{"gcc", "clang", "cc"}, "-std=c17")
+}
+
+// RunCpp compiles C++ using a local `g++` (or `clang++`),
+// then runs the binary under resource limits.
+func RunCpp(program string, opts Options) (*RunResult, error) {
+	return compileAndRun(program, cppFile, []string{"g++", "clang++", "c++"}, "-std=c++17")
+}
+
+// Compiles a program with the first compiler found, then runs the binary
+// under resource limits. Compiler output is the error of the result.
+func compileAndRun(program, file string, compilers []string, args ...string) (*RunResult, error) {
+	compiler, err := lookPath(compilers...)
+	if err != nil {
+		return nil, err
+	}
+	return withTempFile(file, program, func(dir string) (*RunResult, error) {
+		res, err := compileLocal(dir, compiler, append(args, "-o", binFile, file)...)
+		if res != nil || err != nil {
+			return res, err
+		}
+		return runLimited(dir, binFile)
+	})
+}
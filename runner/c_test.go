@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatC(t *testing.T) {
+	requireTool(t, "clang-format")
+	formatted := checkIdempotent(t, FormatC, "int main(){return 0;}\n", Options{Style: "llvm"})
+	if formatted != "int main() { return 0; }\n" {
+		t.Errorf("FormatC() = %q", formatted)
+	}
+}
+
+func TestFormatCpp(t *testing.T) {
+	requireTool(t, "clang-format")
+	checkIdempotent(t, FormatCpp, "int main(){std::vector<int> v;}\n", Options{Style: "google"})
+}
+
+func TestRunC(t *testing.T) {
+	requireTool(t, "gcc", "clang", "cc")
+	res, err := RunC("#include <stdio.h>\nint main(void) { printf(\"%d\\n\", 1 + 2); return 4; }\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "3\n" || res.Status != 4 {
+		t.Errorf("RunC() = %+v, want output 3 and status 4", res)
+	}
+
+	// compiler errors are the errors of the result
+	res, err = RunC("int main(void) { return x; }\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status == 0 || !strings.Contains(res.Errors, "main.c:1:") || res.Output != "" {
+		t.Errorf("RunC() = %+v, want the compiler errors", res)
+	}
+}
+
+func TestRunCpp(t *testing.T) {
+	requireTool(t, "g++", "clang++", "c++")
+	res, err := RunCpp("#include <iostream>\nint main() { std::cout << \"hi\" << std::endl; }\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "hi\n" || res.Status != 0 {
+		t.Errorf("RunCpp() = %+v, want output hi", res)
+	}
+}
+
+func TestFormatClangArgs(t *testing.T) {
+	defer fakeTools(t, map[string]string{"clang-format": `echo "$@"; cat`})()
+	formatted, err := FormatC("int a;\n", Options{Style: "google"})
+	if want := "--assume-filename=main.c --style=google\nint a;\n"; err != nil || formatted != want {
+		t.Errorf("FormatC() = %q, %v, want %q", formatted, err, want)
+	}
+
+	// the default style is clang-format's
+	formatted, err = FormatCpp("int a;\n", Options{})
+	if want := "--assume-filename=main.cpp\nint a;\n"; err != nil || formatted != want {
+		t.Errorf("FormatCpp() = %q, %v, want %q", formatted, err, want)
+	}
+}
+
+func TestFormatClangError(t *testing.T) {
+	defer fakeTools(t, map[string]string{"clang-format": "echo 'Invalid value for -style' >&2; exit 1"})()
+	_, err := FormatC("int a;\n", Options{Style: "nope"})
+	if err == nil || !strings.Contains(err.Error(), "exit status 1 - Invalid value for -style") {
+		t.Errorf("FormatC() error = %v, want the STDERR of clang-format", err)
+	}
+}
+
+func TestCompileAndRunArgs(t *testing.T) {
+	// the compiler writes a binary that prints its arguments
+	compiler := `echo "$@" >&2; printf '#!/bin/sh\necho ran\n' > "$3"; chmod +x "$3"`
+	tests := []struct {
+		name string
+		tool string
+		run  func(string, Options) (*RunResult, error)
+		want string
+	}{
+		{"cc", "cc", RunC, "-std=c17 -o ./main main.c"},
+		{"clang++", "clang++", RunCpp, "-std=c++17 -o ./main main.cpp"},
+	}
+	for _, tt := range tests {
+		restore := fakeTools(t, map[string]string{tt.tool: compiler})
+		res, err := tt.run("", Options{})
+		restore()
+		// the STDERR of a successful compile is ignored
+		if err != nil || res.Output != "ran\n" || res.Status != 0 {
+			t.Errorf("%s: run() = %+v, %v, want output ran", tt.name, res, err)
+		}
+
+		restore = fakeTools(t, map[string]string{tt.tool: `echo "$@"; exit 1`})
+		res, err = tt.run("", Options{})
+		restore()
+		if err != nil || res.Errors != tt.want+"\n" {
+			t.Errorf("%s: run() = %+v, %v, want the arguments %q", tt.name, res, err, tt.want)
+		}
+	}
+}
+
+func TestCompileAndRunFailure(t *testing.T) {
+	// compilers can write their diagnostics to STDOUT
+	program := "int main(void) { return x; }\n"
+	defer fakeTools(t, map[string]string{"gcc": "echo \"main.c:1:25: error: 'x' undeclared\"; exit 1"})()
+	res, err := RunC(program, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Errors != "main.c:1:25: error: 'x' undeclared\n" || res.Output != "" || res.Status != 1 {
+		t.Errorf("RunC() = %+v, want the compiler output as the errors", res)
+	}
+	want := []Diagnostic{{Line: 1, Column: 25, EndLine: 1, EndColumn: 26, Severity: "error", Message: "'x' undeclared"}}
+	if got := ParseDiagnostics(program, res.Errors); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDiagnostics() = %v, want %v", got, want)
+	}
+}
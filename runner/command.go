@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"path/filepath"
+)
+
+const (
+	commandFile = "program" // default file name of a program run by a Command
+)
+
+// Command is a local command that formats or runs a program,
+// as declared by a language definition file.
+type Command struct {
+	Name string   `json:"command"` // executable, looked up in $PATH
+	Args []string `json:"args"`    // arguments before the program's file, if any
+	File string   `json:"file"`    // file name the program is written to when run (e.g. `main.py`)
+}
+
+// Format runs the command with the program on STDIN
+// and returns the formatted program from STDOUT, as well as an error
+// containing the command's STDERR if the command exited with a non-zero code.
+func (c *Command) Format(text string, opts Options) (string, error) {
+	name, err := lookPath(c.Name)
+	if err != nil {
+		return "", err
+	}
+	return formatLocal(text, name, c.Args...)
+}
+
+// Run writes the program to the command's file and runs
+// the command with the file as its last argument under resource limits.
+func (c *Command) Run(program string, opts Options) (*RunResult, error) {
+	name, err := lookPath(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	file := commandFile
+	if c.File != "" {
+		file = filepath.Base(c.File)
+	}
+	return withTempFile(file, program, func(dir string) (*RunResult, error) {
+		return runLimited(dir, name, append(append([]string{}, c.Args...), file)...)
+	})
+}
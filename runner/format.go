@@ -29,7 +29,7 @@ func getGoImportsPath() string {
 // FormatGo runs `goimports` on a Go program as a string
 // and returns the formatted result as well as an error containing
 // the command's STDERR if a the command exited with a non-zero code.
-func FormatGo(text string) (string, error) {
+func FormatGo(text string, opts Options) (string, error) {
 	cmd := exec.Command(goImportsPath)
 	var stdIn, stdOut, stdErr bytes.Buffer
 	cmd.Stdin, cmd.Stdout, cmd.Stderr = &stdIn, &stdOut, &stdErr
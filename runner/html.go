@@ -0,0 +1,29 @@
+package runner
+
+const (
+	cssFile  = "main.css"   // file name for formatting CSS
+	htmlFile = "index.html" // file name for formatting HTML
+	mdFile   = "README.md"  // file name for formatting Markdown
+)
+
+// FormatCSS runs `prettier` on a CSS program as a string
+// and returns the formatted result as well as an error containing
+// the command's STDERR if the command exited with a non-zero code.
+func FormatCSS(text string, opts Options) (string, error) {
+	return formatPrettier(text, cssFile)
+}
+
+// FormatHTML runs `prettier` on an HTML document as a string, which also
+// formats its embedded CSS and JavaScript, and returns the formatted result
+// as well as an error containing the command's STDERR if the command exited
+// with a non-zero code.
+func FormatHTML(text string, opts Options) (string, error) {
+	return formatPrettier(text, htmlFile)
+}
+
+// FormatMarkdown runs `prettier` on a Markdown document as a string
+// and returns the formatted result as well as an error containing
+// the command's STDERR if the command exited with a non-zero code.
+func FormatMarkdown(text string, opts Options) (string, error) {
+	return formatPrettier(text, mdFile)
+}
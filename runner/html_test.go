@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatCSS(t *testing.T) {
+	requireTool(t, "prettier")
+	formatted := checkIdempotent(t, FormatCSS, "a{color:red}\n", Options{})
+	if formatted != "a {\n  color: red;\n}\n" {
+		t.Errorf("FormatCSS() = %q", formatted)
+	}
+}
+
+func TestFormatHTML(t *testing.T) {
+	requireTool(t, "prettier")
+	checkIdempotent(t, FormatHTML, "<div><style>a{color:red}</style><script>let a={b:1}</script></div>\n", Options{})
+}
+
+func TestFormatMarkdown(t *testing.T) {
+	requireTool(t, "prettier")
+	formatted := checkIdempotent(t, FormatMarkdown, "Title\n=====\n\n* a\n* b\n", Options{})
+	if formatted != "# Title\n\n- a\n- b\n" {
+		t.Errorf("FormatMarkdown() = %q", formatted)
+	}
+}
+
+func TestFormatHTMLArgs(t *testing.T) {
+	defer fakeTools(t, map[string]string{"prettier": `echo "$@"; cat`})()
+	tests := []struct {
+		name   string
+		format func(string, Options) (string, error)
+		file   string
+	}{
+		{"css", FormatCSS, "main.css"},
+		{"html", FormatHTML, "index.html"},
+		{"markdown", FormatMarkdown, "README.md"},
+	}
+	for _, tt := range tests {
+		// the parser is inferred from the file, and the document is on STDIN
+		formatted, err := tt.format("a\n", Options{})
+		if want := "--stdin-filepath " + tt.file + "\na\n"; err != nil || formatted != want {
+			t.Errorf("%s: format() = %q, %v, want %q", tt.name, formatted, err, want)
+		}
+	}
+}
+
+func TestFormatCSSError(t *testing.T) {
+	defer fakeTools(t, map[string]string{"prettier": "echo '[error] main.css: CssSyntaxError: Unknown word (1:3)' >&2; exit 2"})()
+	_, err := FormatCSS("a{b}\n", Options{})
+	if err == nil || !strings.Contains(err.Error(), "exit status 2 - [error] main.css: CssSyntaxError: Unknown word (1:3)") {
+		t.Errorf("FormatCSS() error = %v, want the STDERR of prettier", err)
+	}
+}
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	javaFile   = "Main.java" // file name for compiling Java, unless a class is public
+	kotlinFile = "main.kt"   // file name for compiling Kotlin
+	kotlinJar  = "main.jar"  // path of the compiled Kotlin jar
+	classDir   = "classes"   // directory of the compiled Java classes
+	heapLimit  = "-Xmx256m"  // heap limit for the JVM, which does not run under the memory limit
+)
+
+var (
+	// The first top-level class is run, as the single-file source launcher does,
+	// and a public class must be compiled in a file of the same name.
+	javaClass       = regexp.MustCompile("(?m)^(?:(?:public|final|abstract|sealed|strictfp)\\s+)*(?:class|interface|enum|record)\\s+([A-Za-z_$][\\w$]*)")
+	javaPublicClass = regexp.MustCompile("(?m)^(?:(?:final|abstract|sealed|strictfp)\\s+)*public\\s+(?:(?:final|abstract|sealed|strictfp)\\s+)*(?:class|interface|enum|record)\\s+([A-Za-z_$][\\w$]*)")
+)
+
+// FormatJava runs `google-java-format` on a Java program as a string
+// and returns the formatted result as well as an error containing
+// the command's STDERR if the command exited with a non-zero code.
+// The `aosp` style indents with four spaces instead of two.
+func FormatJava(text string, opts Options) (string, error) {
+	googleJavaFormat, err := lookPath("google-java-format")
+	if err != nil {
+		return "", err
+	}
+	args := []string{"-"}
+	if opts.Style == "aosp" {
+		args = append([]string{"--aosp"}, args...)
+	}
+	return formatLocal(text, googleJavaFormat, args...)
+}
+
+// FormatKotlin runs `ktlint` on a Kotlin program as a string
+// and returns the formatted result as well as an error containing
+// the command's STDERR if the command exited with a non-zero code.
+func FormatKotlin(text string, opts Options) (string, error) {
+	ktlint, err := lookPath("ktlint")
+	if err != nil {
+		return "", err
+	}
+	return formatLocal(text, ktlint, "--format", "--stdin", "--log-level=error")
+}
+
+// RunJava compiles Java using a local `javac`, then runs the first
+// top-level class with `java` under resource limits. Without `javac`,
+// the program is run by the single-file source launcher (`java Main.java`).
+func RunJava(program string, opts Options) (*RunResult, error) {
+	java, err := lookPath("java")
+	if err != nil {
+		return nil, err
+	}
+	file := getJavaFile(program)
+	return withTempFile(file, program, func(dir string) (*RunResult, error) {
+		javac, err := lookPath("javac")
+		if err != nil {
+			return runJVM(dir, java, file)
+		}
+		res, err := compileLocal(dir, javac, "-d", classDir, file)
+		if res != nil || err != nil {
+			return res, err
+		}
+		class := javaClass.FindStringSubmatch(program)
+		if class == nil {
+			return &RunResult{Errors: "no top-level class to run", Status: 1}, nil
+		}
+		return runJVM(dir, java, "-cp", classDir, class[1])
+	})
+}
+
+// Gets the file name that a Java program is compiled in,
+// which is the name of its public class if it has one.
+func getJavaFile(program string) string {
+	if res := javaPublicClass.FindStringSubmatch(program); res != nil {
+		return res[1] + ".java"
+	}
+	return javaFile
+}
+
+// RunKotlin compiles Kotlin into a jar using a local `kotlinc`,
+// then runs the jar with `java` under resource limits.
+func RunKotlin(program string, opts Options) (*RunResult, error) {
+	kotlinc, err := lookPath("kotlinc")
+	if err != nil {
+		return nil, err
+	}
+	java, err := lookPath("java")
+	if err != nil {
+		return nil, err
+	}
+	return withTempFile(kotlinFile, program, func(dir string) (*RunResult, error) {
+		res, err := compileLocal(dir, kotlinc, kotlinFile, "-include-runtime", "-d", kotlinJar)
+		if res != nil || err != nil {
+			return res, err
+		}
+		return runJVM(dir, java, "-jar", kotlinJar)
+	})
+}
+
+// Runs the JVM with arguments under resource limits (CPU time and file size),
+// where the heap is limited by the JVM, since it reserves more virtual memory
+// than the shell's memory limit allows.
+func runJVM(dir, java string, args ...string) (*RunResult, error) {
+	limits := fmt.Sprintf("ulimit -t %d; ulimit -f %d; exec \"$0\" \"$@\"", cpuLimit, fileLimit)
+	return runLocal(dir, "sh", append([]string{"-c", limits, java, heapLimit}, args...)...)
+}
@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJavaClass(t *testing.T) {
+	tests := []struct {
+		program string
+		class   string
+		public  string
+	}{
+		{"class A {}\npublic final class B {}\n", "A", "B"},
+		{"final record R(int x) {}\n", "R", ""},
+		{"  class Nested {}\ninterface I {}\n", "I", ""},
+	}
+	for _, tt := range tests {
+		var class, public string
+		if res := javaClass.FindStringSubmatch(tt.program); res != nil {
+			class = res[1]
+		}
+		if res := javaPublicClass.FindStringSubmatch(tt.program); res != nil {
+			public = res[1]
+		}
+		if class != tt.class || public != tt.public {
+			t.Errorf("classes of %q = %q, %q, want %q, %q", tt.program, class, public, tt.class, tt.public)
+		}
+	}
+}
+
+func TestFormatJava(t *testing.T) {
+	requireTool(t, "google-java-format")
+	checkIdempotent(t, FormatJava, "class A{void f(){int x=1;}}\n", Options{Style: "aosp"})
+}
+
+func TestFormatKotlin(t *testing.T) {
+	requireTool(t, "ktlint")
+	checkIdempotent(t, FormatKotlin, "fun main(){println(1)}\n", Options{})
+}
+
+func TestRunJava(t *testing.T) {
+	requireTool(t, "java")
+	// a public class is compiled in a file of its name
+	res, err := RunJava("public class Hello {\n\tpublic static void main(String[] args) {\n\t\tSystem.out.println(1 + 2);\n\t}\n}\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "3\n" || res.Status != 0 {
+		t.Errorf("RunJava() = %+v, want output 3", res)
+	}
+
+	res, err = RunJava("class Main { void f() { return x; } }\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status == 0 || !strings.Contains(res.Errors, "Main.java:1:") {
+		t.Errorf("RunJava() = %+v, want the compiler errors", res)
+	}
+}
+
+func TestRunKotlin(t *testing.T) {
+	requireTool(t, "kotlinc")
+	requireTool(t, "java")
+	res, err := RunKotlin("fun main() {\n\tprintln(1 + 2)\n}\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "3\n" || res.Status != 0 {
+		t.Errorf("RunKotlin() = %+v, want output 3", res)
+	}
+}
+
+func TestFormatJVMArgs(t *testing.T) {
+	defer fakeTools(t, map[string]string{"google-java-format": `echo "$@"; cat`, "ktlint": `echo "$@"; cat`})()
+	tests := []struct {
+		name   string
+		format func(string, Options) (string, error)
+		opts   Options
+		want   string
+	}{
+		{"java", FormatJava, Options{}, "-"},
+		{"java aosp", FormatJava, Options{Style: "aosp"}, "--aosp -"},
+		{"kotlin", FormatKotlin, Options{}, "--format --stdin --log-level=error"},
+	}
+	for _, tt := range tests {
+		formatted, err := tt.format("class A\n", tt.opts)
+		if want := tt.want + "\nclass A\n"; err != nil || formatted != want {
+			t.Errorf("%s: format() = %q, %v, want %q", tt.name, formatted, err, want)
+		}
+	}
+}
+
+func TestRunJavaArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		javac   string // script of javac, or empty for none
+		program string
+		want    RunResult
+	}{
+		// the single-file source launcher runs the file
+		{"launcher", "", "class A {}\n", RunResult{Output: "-Xmx256m Main.java\n"}},
+		{"launcher public", "", "public class Hello {}\n", RunResult{Output: "-Xmx256m Hello.java\n"}},
+		// the first top-level class is run
+		{"compiled", "exit 0", "class A {}\nclass B {}\n", RunResult{Output: "-Xmx256m -cp classes A\n"}},
+		{"no class", "exit 0", "int a;\n", RunResult{Errors: "no top-level class to run", Status: 1}},
+		{"compile failure", "echo \"$@\"; echo 'Main.java:1: error: x' >&2; exit 1", "class A {\n",
+			RunResult{Errors: "-d classes Main.java\nMain.java:1: error: x\n", Status: 1}},
+	}
+	for _, tt := range tests {
+		tools := map[string]string{"java": `echo "$@"`}
+		if tt.javac != "" {
+			tools["javac"] = tt.javac
+		}
+		restore := fakeTools(t, tools)
+		res, err := RunJava(tt.program, Options{})
+		restore()
+		if err != nil || *res != tt.want {
+			t.Errorf("%s: RunJava() = %+v, %v, want %+v", tt.name, res, err, tt.want)
+		}
+	}
+}
+
+func TestRunKotlinArgs(t *testing.T) {
+	restore := fakeTools(t, map[string]string{"kotlinc": "exit 0", "java": `echo "$@"`})
+	res, err := RunKotlin("fun main() {}\n", Options{})
+	restore()
+	if want := "-Xmx256m -jar main.jar\n"; err != nil || res.Output != want || res.Status != 0 {
+		t.Errorf("RunKotlin() = %+v, %v, want output %q", res, err, want)
+	}
+
+	// the compiler output is the errors
+	restore = fakeTools(t, map[string]string{"kotlinc": `echo "$@"; echo 'main.kt:1:5: error: x' >&2; exit 1`, "java": "exit 0"})
+	res, err = RunKotlin("fun\n", Options{})
+	restore()
+	want := RunResult{Errors: "main.kt -include-runtime -d main.jar\nmain.kt:1:5: error: x\n", Status: 1}
+	if err != nil || *res != want {
+		t.Errorf("RunKotlin() = %+v, %v, want %+v", res, err, want)
+	}
+
+	// kotlinc needs java to run the jar
+	defer fakeTools(t, map[string]string{"kotlinc": "exit 0"})()
+	if _, err := RunKotlin("", Options{}); err == nil || !strings.Contains(err.Error(), "java") {
+		t.Errorf("RunKotlin() error = %v, want the missing java", err)
+	}
+}
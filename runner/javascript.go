@@ -0,0 +1,66 @@
+package runner
+
+import "path/filepath"
+
+const (
+	javaScriptFile = "main.js" // file name for running JavaScript
+	typeScriptFile = "main.ts" // file name for running TypeScript
+)
+
+// FormatJavaScript runs `prettier` on a JavaScript program as a string
+// and returns the formatted result as well as an error containing
+// the command's STDERR if the command exited with a non-zero code.
+func FormatJavaScript(text string, opts Options) (string, error) {
+	return formatPrettier(text, javaScriptFile)
+}
+
+// FormatTypeScript runs `prettier` on a TypeScript program as a string
+// and returns the formatted result as well as an error containing
+// the command's STDERR if the command exited with a non-zero code.
+func FormatTypeScript(text string, opts Options) (string, error) {
+	return formatPrettier(text, typeScriptFile)
+}
+
+// Runs a locally installed `prettier`, which infers
+// the parser from the extension of the file path.
+func formatPrettier(text, file string) (string, error) {
+	prettier, err := lookPath("prettier")
+	if err != nil {
+		return "", err
+	}
+	return formatLocal(text, prettier, "--stdin-filepath", file)
+}
+
+// RunJavaScript runs JavaScript using a local `node` (or `deno`).
+func RunJavaScript(program string, opts Options) (*RunResult, error) {
+	runtime, err := lookPath("node", "deno")
+	if err != nil {
+		return nil, err
+	}
+	return withTempFile(javaScriptFile, program, func(dir string) (*RunResult, error) {
+		if isDeno(runtime) {
+			return runLocal(dir, runtime, "run", javaScriptFile)
+		}
+		return runLocal(dir, runtime, javaScriptFile)
+	})
+}
+
+// RunTypeScript runs TypeScript using a local `deno` (or `node`,
+// which must support stripping types).
+func RunTypeScript(program string, opts Options) (*RunResult, error) {
+	runtime, err := lookPath("deno", "node")
+	if err != nil {
+		return nil, err
+	}
+	return withTempFile(typeScriptFile, program, func(dir string) (*RunResult, error) {
+		if isDeno(runtime) {
+			return runLocal(dir, runtime, "run", typeScriptFile)
+		}
+		return runLocal(dir, runtime, "--experimental-strip-types", typeScriptFile)
+	})
+}
+
+// Checks if a runtime path is `deno`.
+func isDeno(runtime string) bool {
+	return filepath.Base(runtime) == "deno"
+}
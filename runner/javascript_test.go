@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatJavaScript(t *testing.T) {
+	requireTool(t, "prettier")
+	formatted := checkIdempotent(t, FormatJavaScript, "const a={b:1}\n", Options{})
+	if formatted != "const a = { b: 1 };\n" {
+		t.Errorf("FormatJavaScript() = %q", formatted)
+	}
+}
+
+func TestFormatTypeScript(t *testing.T) {
+	requireTool(t, "prettier")
+	checkIdempotent(t, FormatTypeScript, "let a:number=1\n", Options{})
+}
+
+func TestRunJavaScript(t *testing.T) {
+	requireTool(t, "node", "deno")
+	res, err := RunJavaScript("console.log(1 + 2)\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "3\n" || res.Status != 0 {
+		t.Errorf("RunJavaScript() = %+v, want output 3", res)
+	}
+
+	res, err = RunJavaScript("throw new Error('boom')\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status == 0 || !strings.Contains(res.Errors, "boom") {
+		t.Errorf("RunJavaScript() = %+v, want a failure with the error", res)
+	}
+
+	// the uncaught error is a diagnostic of the program
+	program := "let a = 1;\nfoo(a)\n"
+	res, err = RunJavaScript(program, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Diagnostic{{Line: 2, Column: 1, EndLine: 2, EndColumn: 4, Severity: "error", Message: "ReferenceError: foo is not defined"}}
+	if got := ParseDiagnostics(program, res.Errors); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDiagnostics() of the errors %q = %v, want %v", res.Errors, got, want)
+	}
+}
+
+func TestFormatPrettierArgs(t *testing.T) {
+	defer fakeTools(t, map[string]string{"prettier": `echo "$@"; cat`})()
+	tests := []struct {
+		format func(string, Options) (string, error)
+		file   string
+	}{
+		{FormatJavaScript, "main.js"},
+		{FormatTypeScript, "main.ts"},
+	}
+	for _, tt := range tests {
+		// the parser is inferred from the file, and the program is on STDIN
+		formatted, err := tt.format("let a\n", Options{})
+		if want := "--stdin-filepath " + tt.file + "\nlet a\n"; err != nil || formatted != want {
+			t.Errorf("format() = %q, %v, want %q", formatted, err, want)
+		}
+	}
+}
+
+func TestFormatPrettierError(t *testing.T) {
+	defer fakeTools(t, map[string]string{"prettier": "echo '[error] main.js: SyntaxError: Unexpected token (1:7)' >&2; exit 2"})()
+	_, err := FormatJavaScript("let a =\n", Options{})
+	if err == nil || !strings.Contains(err.Error(), "exit status 2 - [error] main.js: SyntaxError: Unexpected token (1:7)") {
+		t.Errorf("FormatJavaScript() error = %v, want the STDERR of prettier", err)
+	}
+}
+
+func TestRunJavaScriptArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		tools []string
+		run   func(string, Options) (*RunResult, error)
+		want  string
+	}{
+		{"node", []string{"node"}, RunJavaScript, "main.js"},
+		{"deno", []string{"deno"}, RunJavaScript, "run main.js"},
+		{"node before deno", []string{"node", "deno"}, RunJavaScript, "main.js"},
+		{"typescript deno before node", []string{"node", "deno"}, RunTypeScript, "run main.ts"},
+		{"typescript node", []string{"node"}, RunTypeScript, "--experimental-strip-types main.ts"},
+	}
+	for _, tt := range tests {
+		tools := make(map[string]string)
+		for _, tool := range tt.tools {
+			tools[tool] = `echo "$@"; cat main.*`
+		}
+		restore := fakeTools(t, tools)
+		res, err := tt.run("1\n", Options{})
+		restore()
+		if want := tt.want + "\n1\n"; err != nil || res.Output != want || res.Status != 0 {
+			t.Errorf("%s: run() = %+v, %v, want output %q", tt.name, res, err, want)
+		}
+	}
+}
+
+func TestRunJavaScriptFailure(t *testing.T) {
+	restore := fakeTools(t, map[string]string{"node": "echo out; echo 'Error: boom' >&2; exit 1"})
+	res, err := RunJavaScript("", Options{})
+	restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "out\nError: boom\n" || res.Errors != "Error: boom\n" || res.Status != 1 {
+		t.Errorf("RunJavaScript() = %+v, want STDERR as the errors and status 1", res)
+	}
+
+	// no runtime
+	defer fakeTools(t, nil)()
+	if _, err := RunTypeScript("", Options{}); err == nil || !strings.Contains(err.Error(), "deno") {
+		t.Errorf("RunTypeScript() error = %v, want the missing runtimes", err)
+	}
+}
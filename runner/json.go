@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const jsonIndent = "  " // indentation for formatting JSON and YAML
+
+// FormatJSON pretty-prints JSON, keeping the order of the keys,
+// and returns an error with the line and column if it is invalid.
+func FormatJSON(text string, opts Options) (string, error) {
+	var b bytes.Buffer
+	if err := json.Indent(&b, []byte(text), "", jsonIndent); err != nil {
+		return "", getJSONError(text, err)
+	}
+	// the indented JSON keeps any trailing whitespace of the text
+	return strings.TrimRight(b.String(), " \t\r\n") + "\n", nil
+}
+
+// RunJSON validates JSON, and if the options have a schema,
+// validates the data against the schema. Problems are the errors of the result.
+func RunJSON(program string, opts Options) (*RunResult, error) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(program), &data); err != nil {
+		return &RunResult{Errors: getJSONError(program, err).Error(), Status: 1}, nil
+	}
+	return validateSchema("JSON", []interface{}{data}, opts.Schema)
+}
+
+// Adds the line and column to a JSON syntax error.
+func getJSONError(text string, err error) error {
+	if e, ok := err.(*json.SyntaxError); ok {
+		// the offset is after the invalid byte
+		line, column := getLineColumn(text, int(e.Offset)-1)
+		return fmt.Errorf("%d:%d: %v", line, column, err)
+	}
+	return err
+}
+
+// Validates data (one value per document) against a schema in SchemaDir,
+// where an empty schema only reports that the data is valid.
+// Problems are the errors of the result.
+func validateSchema(kind string, documents []interface{}, schema string) (*RunResult, error) {
+	if schema == "" {
+		return &RunResult{Output: fmt.Sprintf("valid %s", kind)}, nil
+	}
+
+	// load by reference so that the schema can refer to other local schemas
+	path, err := filepath.Abs(filepath.Join(SchemaDir, schema+".json"))
+	if err != nil {
+		return nil, err
+	}
+	schemaLoader := gojsonschema.NewReferenceLoader("file://" + filepath.ToSlash(path))
+
+	var problems []string
+	for i, data := range documents {
+		res, err := gojsonschema.Validate(schemaLoader, gojsonschema.NewGoLoader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate against schema `%s`: %v", schema, err)
+		}
+		for _, e := range res.Errors() {
+			if len(documents) > 1 {
+				problems = append(problems, fmt.Sprintf("document %d: %s", i+1, e))
+			} else {
+				problems = append(problems, e.String())
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &RunResult{Errors: strings.Join(problems, "\n"), Status: 1}, nil
+	}
+	return &RunResult{Output: fmt.Sprintf("valid %s (schema `%s`)", kind, schema)}, nil
+}
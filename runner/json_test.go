@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatJSON(t *testing.T) {
+	tests := []struct {
+		program string
+		want    string
+	}{
+		{"{\"a\":1}", "{\n  \"a\": 1\n}\n"},
+		{"{\"a\":1}\n", "{\n  \"a\": 1\n}\n"},
+		{"  {\"b\":[1,2],\"a\":null}\n\n\n", "{\n  \"b\": [\n    1,\n    2\n  ],\n  \"a\": null\n}\n"},
+	}
+	for _, tt := range tests {
+		if got := checkIdempotent(t, FormatJSON, tt.program, Options{}); got != tt.want {
+			t.Errorf("FormatJSON(%q) = %q, want %q", tt.program, got, tt.want)
+		}
+	}
+}
+
+func TestFormatJSONError(t *testing.T) {
+	_, err := FormatJSON("{\n  \"a\": 1,\n  \"b\" 2\n}\n", Options{})
+	if err == nil || !strings.HasPrefix(err.Error(), "3:7: ") {
+		t.Errorf("FormatJSON() error = %v, want it at 3:7", err)
+	}
+}
+
+func TestFormatYAML(t *testing.T) {
+	tests := []struct {
+		program string
+		want    string
+	}{
+		{"a:   1 # one\nb:\n    - x\n    - y\n", "a: 1 # one\nb:\n  - x\n  - y\n"},
+		{"a: 1\n---\nb: 2\n", "a: 1\n---\nb: 2\n"},
+		{"# only a comment\n", "# only a comment\n"},
+	}
+	for _, tt := range tests {
+		if got := checkIdempotent(t, FormatYAML, tt.program, Options{}); got != tt.want {
+			t.Errorf("FormatYAML(%q) = %q, want %q", tt.program, got, tt.want)
+		}
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	res, err := RunJSON("{\"a\": 1}", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "valid JSON" || res.Status != 0 {
+		t.Errorf("RunJSON() = %+v, want valid JSON", res)
+	}
+
+	res, err = RunJSON("{\"a\": 1,}", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status == 0 || !strings.HasPrefix(res.Errors, "1:9: ") {
+		t.Errorf("RunJSON() = %+v, want an error at 1:9", res)
+	}
+}
+
+func TestRunYAML(t *testing.T) {
+	res, err := RunYAML("a: [1\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status == 0 || !strings.Contains(res.Errors, "line ") {
+		t.Errorf("RunYAML() = %+v, want an error with the line", res)
+	}
+
+	res, err = RunYAML("# nothing\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status == 0 || res.Errors != "no YAML documents" {
+		t.Errorf("RunYAML() = %+v, want no documents", res)
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(schemaDir string) { SchemaDir = schemaDir }(SchemaDir)
+	SchemaDir = dir
+
+	schema := `{"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "person.json"), []byte(schema), 0600); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Schema: "person"}
+
+	res, err := RunJSON("{\"name\": \"ada\"}", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != 0 || res.Output != "valid JSON (schema `person`)" {
+		t.Errorf("RunJSON() = %+v, want valid JSON", res)
+	}
+
+	res, err = RunYAML("name: ada\n---\nname: 1\n", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status == 0 || !strings.HasPrefix(res.Errors, "document 2: name: ") {
+		t.Errorf("RunYAML() = %+v, want the second document to be invalid", res)
+	}
+
+	if _, err = RunJSON("{}", Options{Schema: "missing"}); err == nil {
+		t.Error("RunJSON() with a missing schema succeeded, want an error")
+	}
+}
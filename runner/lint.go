@@ -0,0 +1,197 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	goFile    = "prog.go"          // file name of a program compiled by the Go Playground
+	stdinFile = "<standard input>" // file name of a program formatted from STDIN (e.g. by `goimports`)
+)
+
+var (
+	// A diagnostic of a compiler or formatter, such as `prog.go:5:2: undefined: x`,
+	// where the column can be missing.
+	diagnosticRegex = regexp.MustCompile("(?m)(?:^|\\s)(?:\\./)?([^\\s:]+|<standard input>):(\\d+)(?::(\\d+))?: (.+)$")
+
+	// A diagnostic of a shell or the YAML decoder, such as `main.sh: line 2: syntax error`.
+	lineDiagnosticRegex = regexp.MustCompile("(?m)^([^\\s:]+): line (\\d+): (.+)$")
+
+	// A diagnostic of `rustc`, whose location follows its message,
+	// such as `error[E0425]: cannot find value` then ` --> main.rs:1:21`.
+	rustDiagnosticRegex = regexp.MustCompile("(?m)^((?:error|warning)(?:\\[\\w+\\])?: .+)\\n\\s*--> (?:\\./)?(\\S+):(\\d+):(\\d+)$")
+
+	// An uncaught error of `node`, whose location is followed by the line of code,
+	// a caret under its column and the error, such as `/tmp/main.js:2` then
+	// `foo(a)`, `^` and `ReferenceError: foo is not defined`.
+	nodeDiagnosticRegex = regexp.MustCompile("(?m)^(\\S+):(\\d+)\\n.*\\n( *)\\^+\\n\\n(\\w*Error: .+)$")
+
+	// A diagnostic of a validator of the program without a file,
+	// such as `3:8: invalid character` of JSON.
+	bareDiagnosticRegex = regexp.MustCompile("(?m)^(\\d+):(\\d+): (.+)$")
+
+	// The severity at the start of a diagnostic's message,
+	// such as `error: ` or `error[E0425]: `.
+	severityRegex = regexp.MustCompile("^(fatal error|error|warning|note)(?:\\[\\w+\\])?: ")
+
+	// The word at the column of a diagnostic.
+	wordRegex = regexp.MustCompile("^\\w+")
+
+	// Severities of diagnostic messages that are not errors.
+	severities = map[string]string{
+		"warning": "warning",
+		"note":    "info",
+	}
+)
+
+// Diagnostic represents a problem found in a program by a linter.
+// Lines and columns are one-based and count runes, where
+// the end column is exclusive.
+type Diagnostic struct {
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+	Severity  string // `error`, `warning`, `info` or `style`
+	Message   string
+}
+
+// IsError checks if the diagnostic is an error
+// (rather than a warning or suggestion).
+func (d Diagnostic) IsError() bool {
+	return d.Severity == "error"
+}
+
+// String formats the diagnostic as `line:column: severity: message`.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, d.Severity, d.Message)
+}
+
+// Gets the one-based line and rune column of a utf8 index in a string.
+func getLineColumn(s string, index int) (line, column int) {
+	if index > len(s) {
+		index = len(s)
+	}
+	before := s[:index]
+	line = strings.Count(before, "\n") + 1
+	column = utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:]) + 1
+	return
+}
+
+// GetUTF8Index gets the utf8 index in a string of a one-based line and rune column.
+// Columns past the end of a line are clamped to its newline,
+// and lines past the end of the string are clamped to its end.
+func GetUTF8Index(s string, line, column int) int {
+	var index int
+	for ; line > 1; line-- {
+		newline := strings.IndexByte(s[index:], '\n')
+		if newline == -1 {
+			return len(s)
+		}
+		index += newline + 1
+	}
+	for ; column > 1 && index < len(s) && s[index] != '\n'; column-- {
+		_, size := utf8.DecodeRuneInString(s[index:])
+		index += size
+	}
+	return index
+}
+
+// Checks if the file of a diagnostic is the file of the program, as the runners
+// and formatters name it, rather than another file (e.g. of a library).
+func isProgramFile(file, program string) bool {
+	switch file {
+	case goFile, stdinFile, cFile, cppFile, rustFile, javaScriptFile, typeScriptFile, kotlinFile, bashFile, yamlSource, commandFile:
+		return true
+	}
+	return file == getJavaFile(program)
+}
+
+// ParseDiagnostics parses the diagnostics of the program in the errors of a compiler
+// or formatter, such as `prog.go:5:2: undefined: x`, which are errors unless their
+// message starts with another severity. Diagnostics of other files are ignored.
+// A diagnostic spans the word at its column, or its whole line if it has no column.
+func ParseDiagnostics(program, errors string) []Diagnostic {
+	type located struct {
+		index int // utf8 index in the errors
+		Diagnostic
+	}
+	var found []located
+	add := func(index int, file, line, column, message string) {
+		if file != "" && !isProgramFile(file, program) {
+			return
+		}
+		if d, ok := newDiagnostic(program, line, column, message); ok {
+			found = append(found, located{index, d})
+		}
+	}
+
+	for _, res := range diagnosticRegex.FindAllStringSubmatchIndex(errors, -1) {
+		add(res[0], errors[res[2]:res[3]], errors[res[4]:res[5]], getSubmatch(errors, res, 3), errors[res[8]:res[9]])
+	}
+	for _, res := range lineDiagnosticRegex.FindAllStringSubmatchIndex(errors, -1) {
+		add(res[0], errors[res[2]:res[3]], errors[res[4]:res[5]], "", errors[res[6]:res[7]])
+	}
+	for _, res := range rustDiagnosticRegex.FindAllStringSubmatchIndex(errors, -1) {
+		add(res[0], errors[res[4]:res[5]], errors[res[6]:res[7]], errors[res[8]:res[9]], errors[res[2]:res[3]])
+	}
+	for _, res := range nodeDiagnosticRegex.FindAllStringSubmatchIndex(errors, -1) {
+		// the file is in a temporary directory
+		file := filepath.Base(errors[res[2]:res[3]])
+		column := strconv.Itoa(res[7] - res[6] + 1)
+		add(res[0], file, errors[res[4]:res[5]], column, errors[res[8]:res[9]])
+	}
+	for _, res := range bareDiagnosticRegex.FindAllStringSubmatchIndex(errors, -1) {
+		add(res[0], "", errors[res[2]:res[3]], errors[res[4]:res[5]], errors[res[6]:res[7]])
+	}
+
+	// in the order of the errors
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].index < found[j].index
+	})
+	var diagnostics []Diagnostic
+	for _, f := range found {
+		diagnostics = append(diagnostics, f.Diagnostic)
+	}
+	return diagnostics
+}
+
+// Gets a submatch of a string by its submatch indices, which is empty if it did not match.
+func getSubmatch(s string, res []int, i int) string {
+	if res[2*i] < 0 {
+		return ""
+	}
+	return s[res[2*i]:res[2*i+1]]
+}
+
+// Gets the diagnostic of a program at a line and an optional column, where the message
+// can start with its severity. It is not ok if the line is not a number.
+func newDiagnostic(program, lineText, columnText, message string) (Diagnostic, bool) {
+	line, err := strconv.Atoi(lineText)
+	if err != nil {
+		return Diagnostic{}, false
+	}
+	d := Diagnostic{Line: line, EndLine: line, Severity: "error", Message: message}
+	if sev := severityRegex.FindStringSubmatch(d.Message); sev != nil {
+		d.Message = d.Message[len(sev[0]):]
+		if severity, ok := severities[sev[1]]; ok {
+			d.Severity = severity
+		}
+	}
+
+	if d.Column, err = strconv.Atoi(columnText); err != nil {
+		// columns past the end of the line end at its newline
+		d.Column, d.EndColumn = 1, math.MaxInt32
+	} else {
+		word := wordRegex.FindString(program[GetUTF8Index(program, d.Line, d.Column):])
+		d.EndColumn = d.Column + utf8.RuneCountInString(word)
+	}
+	return d, true
+}
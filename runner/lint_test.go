@@ -0,0 +1,207 @@
+package runner
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGetLineColumn(t *testing.T) {
+	s := "ab\n\té😀c"
+	tests := []struct {
+		index        int
+		line, column int
+	}{
+		{0, 1, 1},
+		{2, 1, 3},
+		{3, 2, 1},
+		{4, 2, 2},
+		{10, 2, 4},
+		{99, 2, 5}, // past the end
+	}
+	for _, tt := range tests {
+		if line, column := getLineColumn(s, tt.index); line != tt.line || column != tt.column {
+			t.Errorf("getLineColumn(%d) = %d:%d, want %d:%d", tt.index, line, column, tt.line, tt.column)
+		}
+	}
+}
+
+func TestGetUTF8Index(t *testing.T) {
+	s := "ab\n\té😀c\n"
+	tests := []struct {
+		line, column int
+		want         int
+	}{
+		{1, 1, 0},
+		{1, 2, 1},
+		{1, 9, 2}, // past the end of the line
+		{2, 1, 3},
+		{2, 2, 4}, // a tab is one column
+		{2, 3, 6},
+		{2, 4, 10},
+		{3, 1, 12},
+		{9, 1, 12}, // past the end of the string
+	}
+	for _, tt := range tests {
+		if got := GetUTF8Index(s, tt.line, tt.column); got != tt.want {
+			t.Errorf("GetUTF8Index(%d, %d) = %d, want %d", tt.line, tt.column, got, tt.want)
+		}
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	d := Diagnostic{Line: 2, Column: 6, Severity: "warning", Message: "unused"}
+	if got, want := d.String(), "2:6: warning: unused"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if d.IsError() {
+		t.Error("warning IsError() = true")
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	tests := []struct {
+		name    string
+		program string
+		errors  string
+		want    []Diagnostic
+	}{
+		{
+			name:    "gcc",
+			program: "int main() { return x; }\n",
+			errors: "main.c: In function 'main':\n" +
+				"main.c:1:21: error: 'x' undeclared (first use in this function)\n" +
+				"    1 | int main() { return x; }\n" +
+				"      |                     ^\n" +
+				"main.c:1:21: note: each undeclared identifier is reported only once\n",
+			want: []Diagnostic{
+				{Line: 1, Column: 21, EndLine: 1, EndColumn: 22, Severity: "error", Message: "'x' undeclared (first use in this function)"},
+				{Line: 1, Column: 21, EndLine: 1, EndColumn: 22, Severity: "info", Message: "each undeclared identifier is reported only once"},
+			},
+		},
+		{
+			name:    "rustc",
+			program: "fn main() { let y = xs; }\n",
+			errors: "error[E0425]: cannot find value `xs` in this scope\n" +
+				" --> main.rs:1:21\n" +
+				"  |\n" +
+				"1 | fn main() { let y = xs; }\n" +
+				"  |                     ^^ not found in this scope\n",
+			want: []Diagnostic{
+				{Line: 1, Column: 21, EndLine: 1, EndColumn: 23, Severity: "error", Message: "cannot find value `xs` in this scope"},
+			},
+		},
+		{
+			name:    "go playground",
+			program: "package main\nfunc main() { x }\n",
+			errors:  "./prog.go:2:15: undefined: x\n\nGo build failed.",
+			want: []Diagnostic{
+				{Line: 2, Column: 15, EndLine: 2, EndColumn: 16, Severity: "error", Message: "undefined: x"},
+			},
+		},
+		{
+			name:    "gofmt",
+			program: "package main\nfunc main() { x := }\n",
+			errors:  "<standard input>:2:20: expected operand, found '}'",
+			want: []Diagnostic{
+				{Line: 2, Column: 20, EndLine: 2, EndColumn: 20, Severity: "error", Message: "expected operand, found '}'"},
+			},
+		},
+		{
+			name:    "javac",
+			program: "class Main { int x = y; }\n",
+			errors: "Main.java:1: error: cannot find symbol\n" +
+				"class Main { int x = y; }\n" +
+				"                     ^\n" +
+				"1 error\n",
+			want: []Diagnostic{
+				{Line: 1, Column: 1, EndLine: 1, EndColumn: math.MaxInt32, Severity: "error", Message: "cannot find symbol"},
+			},
+		},
+		{
+			name:    "javac public class",
+			program: "public class Foo { int x = y; }\n",
+			errors:  "Foo.java:1: warning: [deprecation] Date in java.util has been deprecated\n",
+			want: []Diagnostic{
+				{Line: 1, Column: 1, EndLine: 1, EndColumn: math.MaxInt32, Severity: "warning", Message: "[deprecation] Date in java.util has been deprecated"},
+			},
+		},
+		{
+			name:    "kotlinc",
+			program: "fun main() { yy }\n",
+			errors:  "main.kt:1:14: error: unresolved reference: yy\n",
+			want: []Diagnostic{
+				{Line: 1, Column: 14, EndLine: 1, EndColumn: 16, Severity: "error", Message: "unresolved reference: yy"},
+			},
+		},
+		{
+			name:    "node",
+			program: "let a = 1;\nfoo(a)\n",
+			errors: "/tmp/gdocs-1/main.js:2\n" +
+				"foo(a)\n" +
+				"^\n\n" +
+				"ReferenceError: foo is not defined\n" +
+				"    at Object.<anonymous> (/tmp/gdocs-1/main.js:2:1)\n" +
+				"    at node:internal/main/run_main_module:28:49\n",
+			want: []Diagnostic{
+				{Line: 2, Column: 1, EndLine: 2, EndColumn: 4, Severity: "error", Message: "ReferenceError: foo is not defined"},
+			},
+		},
+		{
+			name:    "node syntax error",
+			program: "let a = ;\n",
+			errors:  "/tmp/gdocs-1/main.js:1\nlet a = ;\n        ^\n\nSyntaxError: Unexpected token ';'\n",
+			want: []Diagnostic{
+				{Line: 1, Column: 9, EndLine: 1, EndColumn: 9, Severity: "error", Message: "SyntaxError: Unexpected token ';'"},
+			},
+		},
+		{
+			name:    "bash",
+			program: "if true; then\necho hi\n",
+			errors:  "main.sh: line 2: syntax error: unexpected end of file\n",
+			want: []Diagnostic{
+				{Line: 2, Column: 1, EndLine: 2, EndColumn: math.MaxInt32, Severity: "error", Message: "syntax error: unexpected end of file"},
+			},
+		},
+		{
+			name:    "yaml",
+			program: "a: b\nc: d: e\n",
+			errors:  "yaml: line 2: mapping values are not allowed in this context",
+			want: []Diagnostic{
+				{Line: 2, Column: 1, EndLine: 2, EndColumn: math.MaxInt32, Severity: "error", Message: "mapping values are not allowed in this context"},
+			},
+		},
+		{
+			name:    "json",
+			program: "{\n  \"a\": tru\n}\n",
+			errors:  "2:8: invalid character 'u' in literal true (expecting 'e')",
+			want: []Diagnostic{
+				{Line: 2, Column: 8, EndLine: 2, EndColumn: 11, Severity: "error", Message: "invalid character 'u' in literal true (expecting 'e')"},
+			},
+		},
+		{
+			name:    "other files",
+			program: "#include \"other.h\"\nint main() { return x; }\n",
+			errors: "In file included from main.c:1:\n" +
+				"other.h:1:1: error: unknown type name 'foo'\n" +
+				"/usr/include/stdio.h:3:1: warning: empty declaration\n" +
+				"main.c:2:21: error: 'x' undeclared\n" +
+				"./other.js:1:1: error: unexpected token\n",
+			want: []Diagnostic{
+				{Line: 2, Column: 21, EndLine: 2, EndColumn: 22, Severity: "error", Message: "'x' undeclared"},
+			},
+		},
+		{
+			name:    "no diagnostics",
+			program: "int main() {}\n",
+			errors:  "collect2: error: ld returned 1 exit status\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseDiagnostics(tt.program, tt.errors); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDiagnostics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
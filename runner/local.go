@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// RunTimeout is how long a program may run locally before it is killed.
+	RunTimeout = 10 * time.Second
+
+	cpuLimit    = 10     // seconds of CPU time for a compiled binary
+	memoryLimit = 524288 // KiB of virtual memory for a compiled binary
+	fileLimit   = 10240  // KiB of written files for a compiled binary
+)
+
+// Gets the path of the first executable found in $PATH.
+func lookPath(names ...string) (string, error) {
+	for _, name := range names {
+		if p, err := exec.LookPath(name); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("none of %q found in $PATH", names)
+}
+
+// Runs a formatter that reads a program from STDIN and writes
+// the formatted program to STDOUT, returning an error containing
+// the command's STDERR if the command exited with a non-zero code.
+func formatLocal(text, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	var stdOut, stdErr bytes.Buffer
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewBufferString(text), &stdOut, &stdErr
+
+	err := cmd.Run()
+	if _, ok := err.(*exec.ExitError); ok {
+		return "", fmt.Errorf("%v - %s", err, stdErr.String())
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to run `%s`: %v", cmd, err)
+	}
+	return stdOut.String(), nil
+}
+
+// Runs a command until it exits or RunTimeout elapses, combining its STDOUT and STDERR
+// into the output. A non-zero exit code is the status of the result, whose errors
+// are the command's STDERR, and a timeout is an error of the result.
+// An error is returned if the command could not be run.
+func runLocal(dir, name string, args ...string) (*RunResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), RunTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	var mu sync.Mutex
+	var out bytes.Buffer
+	stdErr := &streamWriter{mu: &mu, out: &out}
+	cmd.Dir, cmd.Stdout, cmd.Stderr = dir, &streamWriter{mu: &mu, out: &out}, stdErr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return &RunResult{Output: out.String(), Errors: fmt.Sprintf("timeout running program (%v)", RunTimeout), Status: -1}, nil
+	}
+	if e, ok := err.(*exec.ExitError); ok {
+		return &RunResult{Output: out.String(), Errors: stdErr.stream.String(), Status: e.ExitCode()}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to run `%s`: %v", cmd, err)
+	}
+	return &RunResult{Output: out.String()}, nil
+}
+
+// A writer of a stream of a command (STDOUT or STDERR), which also writes
+// to the output combining its streams. The streams are written concurrently,
+// so they share a lock.
+type streamWriter struct {
+	stream bytes.Buffer
+	mu     *sync.Mutex
+	out    *bytes.Buffer
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.out.Write(p)
+	return w.stream.Write(p)
+}
+
+// Runs a compiler, returning a result containing the compiler's
+// output as the error if it failed, otherwise a nil result.
+func compileLocal(dir, name string, args ...string) (*RunResult, error) {
+	res, err := runLocal(dir, name, args...)
+	if err != nil || (res.Status == 0 && res.Errors == "") {
+		return nil, err
+	}
+	if res.Status > 0 {
+		res.Errors = res.Output // the diagnostics can be on STDOUT too
+	}
+	res.Output = ""
+	return res, nil
+}
+
+// Runs a compiled binary (or an interpreter) with arguments under resource
+// limits (CPU time, memory and file size) using the shell's `ulimit`.
+func runLimited(dir, bin string, args ...string) (*RunResult, error) {
+	limits := fmt.Sprintf("ulimit -t %d; ulimit -v %d; ulimit -f %d; exec \"$0\" \"$@\"", cpuLimit, memoryLimit, fileLimit)
+	return runLocal(dir, "sh", append([]string{"-c", limits, bin}, args...)...)
+}
+
+// Writes a program to a file in a new temporary directory, calling f
+// with the directory. Commands run in the directory can refer to the file
+// by its name, so that diagnostics do not contain the temporary path.
+// The directory is removed after f returns.
+func withTempFile(file, program string, f func(dir string) (*RunResult, error)) (*RunResult, error) {
+	dir, err := ioutil.TempDir("", "gdocs-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err = ioutil.WriteFile(filepath.Join(dir, file), []byte(program), 0600); err != nil {
+		return nil, err
+	}
+	return f(dir)
+}
@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Skips the test if none of the executables are found in $PATH.
+func requireTool(t *testing.T, names ...string) {
+	t.Helper()
+	if _, err := lookPath(names...); err != nil {
+		t.Skip(err)
+	}
+}
+
+// Replaces $PATH with a temporary directory of fake tools, which are shell scripts
+// by name, so that the commands of runners can be tested without the tools.
+// The directory also has the `sh`, `cat` and `chmod` that the scripts can use.
+// It returns a func that restores $PATH and removes the directory.
+func fakeTools(t *testing.T, tools map[string]string) func() {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "gdocs-tools-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sh string
+	for _, name := range []string{"sh", "cat", "chmod"} {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			os.RemoveAll(dir)
+			t.Skip(err)
+		}
+		if name == "sh" {
+			sh = path
+		}
+		if err = os.Symlink(path, filepath.Join(dir, name)); err != nil {
+			os.RemoveAll(dir)
+			t.Fatal(err)
+		}
+	}
+	for name, script := range tools {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte("#!"+sh+"\n"+script+"\n"), 0700); err != nil {
+			os.RemoveAll(dir)
+			t.Fatal(err)
+		}
+	}
+
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir)
+	return func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+	}
+}
+
+// Checks that formatting a program twice gives the same output as formatting it once.
+func checkIdempotent(t *testing.T, format func(string, Options) (string, error), program string, opts Options) string {
+	t.Helper()
+	once, err := format(program, opts)
+	if err != nil {
+		t.Fatalf("format error = %v", err)
+	}
+	twice, err := format(once, opts)
+	if err != nil {
+		t.Fatalf("format of formatted program error = %v", err)
+	}
+	if once != twice {
+		t.Errorf("formatting twice = %q, want %q", twice, once)
+	}
+	return once
+}
+
+func TestLookPath(t *testing.T) {
+	if _, err := lookPath("gdocs-missing-tool", "sh"); err != nil {
+		t.Errorf("lookPath() error = %v, want the path of `sh`", err)
+	}
+	if _, err := lookPath("gdocs-missing-tool"); err == nil || !strings.Contains(err.Error(), "gdocs-missing-tool") {
+		t.Errorf("lookPath() error = %v, want it to name the missing tool", err)
+	}
+}
+
+func TestRunLocal(t *testing.T) {
+	res, err := runLocal("", "sh", "-c", "echo out; echo err >&2; exit 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "out\nerr\n" || res.Errors != "err\n" || res.Status != 3 {
+		t.Errorf("runLocal() = %+v, want the combined output, STDERR as the errors and status 3", res)
+	}
+
+	// STDERR is output if the command succeeds
+	res, err = runLocal("", "sh", "-c", "echo err >&2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "err\n" || res.Errors != "" || res.Status != 0 {
+		t.Errorf("runLocal() = %+v, want STDERR as the output", res)
+	}
+}
+
+func TestWithTempFile(t *testing.T) {
+	var tempDir string
+	res, err := withTempFile("main.txt", "program", func(dir string) (*RunResult, error) {
+		tempDir = dir
+		b, err := ioutil.ReadFile(filepath.Join(dir, "main.txt"))
+		return &RunResult{Output: string(b)}, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "program" {
+		t.Errorf("file content = %q, want %q", res.Output, "program")
+	}
+	if _, err := ioutil.ReadDir(tempDir); err == nil {
+		t.Errorf("temporary directory %s was not removed", tempDir)
+	}
+}
@@ -0,0 +1,31 @@
+package runner
+
+const (
+	// FixtureDirEnv is the environment variable that
+	// overrides the default fixture directory.
+	FixtureDirEnv = "GDOCS_FIXTURES"
+	// SchemaDirEnv is the environment variable that
+	// overrides the default schema directory.
+	SchemaDirEnv = "GDOCS_SCHEMAS"
+)
+
+var (
+	// FixtureDir is the directory of local fixture files (`<name>.sql`)
+	// that can seed the database before running SQL, via #fixture=<name>.
+	// The bot defaults it to the directory inside the user config dir,
+	// unless $GDOCS_FIXTURES is set.
+	FixtureDir = "fixtures"
+	// SchemaDir is the directory of local JSON Schema files (`<name>.json`)
+	// that can validate JSON and YAML data when run, via #schema=<name>.
+	// The bot defaults it to the directory inside the user config dir,
+	// unless $GDOCS_SCHEMAS is set.
+	SchemaDir = "schemas"
+)
+
+// Options are the settings from config directives
+// that are passed to formatters and runners.
+type Options struct {
+	Style   string // formatter style (e.g. `google` for `clang-format`), empty for the formatter's default
+	Fixture string // name of the fixture file in FixtureDir that seeds the database, for SQL
+	Schema  string // name of the JSON Schema file in SchemaDir that validates the data, for JSON and YAML
+}
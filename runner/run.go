@@ -37,7 +37,7 @@ type RunResult struct {
 }
 
 // RunGo runs Go using Go Playground's server.
-func RunGo(program string) (*RunResult, error) {
+func RunGo(program string, opts Options) (*RunResult, error) {
 	// marshal payload
 	payload, err := json.Marshal(goPlaygroundRequest{program})
 	if err != nil {
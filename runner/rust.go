@@ -0,0 +1,23 @@
+package runner
+
+const (
+	rustFile    = "main.rs" // file name for compiling Rust
+	rustEdition = "2021"    // edition for formatting and compiling Rust
+)
+
+// FormatRust runs `rustfmt` on a Rust program as a string
+// and returns the formatted result as well as an error containing
+// the command's STDERR if the command exited with a non-zero code.
+func FormatRust(text string, opts Options) (string, error) {
+	rustfmt, err := lookPath("rustfmt")
+	if err != nil {
+		return "", err
+	}
+	return formatLocal(text, rustfmt, "--edition", rustEdition, "--emit", "stdout")
+}
+
+// RunRust compiles Rust using a local `rustc` (without cargo),
+// then runs the binary under resource limits.
+func RunRust(program string, opts Options) (*RunResult, error) {
+	return compileAndRun(program, rustFile, []string{"rustc"}, "--edition", rustEdition, "--color", "never")
+}
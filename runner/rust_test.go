@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatRust(t *testing.T) {
+	requireTool(t, "rustfmt")
+	formatted := checkIdempotent(t, FormatRust, "fn main(){let a=1;}\n", Options{})
+	if formatted != "fn main() {\n    let a = 1;\n}\n" {
+		t.Errorf("FormatRust() = %q", formatted)
+	}
+}
+
+func TestRunRust(t *testing.T) {
+	requireTool(t, "rustc")
+	res, err := RunRust("fn main() {\n    println!(\"{}\", 1 + 2);\n}\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Output != "3\n" || res.Status != 0 {
+		t.Errorf("RunRust() = %+v, want output 3", res)
+	}
+
+	res, err = RunRust("fn main() {\n    x;\n}\n", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status == 0 || !strings.Contains(res.Errors, "main.rs:2:5") {
+		t.Errorf("RunRust() = %+v, want the compiler errors", res)
+	}
+}
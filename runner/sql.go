@@ -0,0 +1,505 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	_ "modernc.org/sqlite" // registers the `sqlite` driver
+)
+
+var (
+	// keywords that are uppercased when formatting
+	sqlKeywords = toSet("ADD", "ALL", "ALTER", "AND", "AS", "ASC", "AUTOINCREMENT", "AUTO_INCREMENT", "BEGIN", "BETWEEN", "BY",
+		"CASCADE", "CASE", "CHECK", "COLUMN", "COMMIT", "CONFLICT", "CONSTRAINT", "CREATE", "CROSS", "DEFAULT", "DELETE", "DESC",
+		"DISTINCT", "DO", "DROP", "ELSE", "END", "EXCEPT", "EXISTS", "EXPLAIN", "FALSE", "FOREIGN", "FROM", "FULL", "GROUP",
+		"HAVING", "IF", "IGNORE", "ILIKE", "IN", "INDEX", "INNER", "INSERT", "INTERSECT", "INTO", "IS", "JOIN", "KEY", "LEFT",
+		"LIKE", "LIMIT", "NATURAL", "NOT", "NOTHING", "NULL", "OFFSET", "ON", "OR", "ORDER", "OUTER", "OVER", "PARTITION",
+		"PRAGMA", "PRIMARY", "RECURSIVE", "REFERENCES", "REPLACE", "RETURNING", "RIGHT", "ROLLBACK", "SELECT", "SET", "TABLE",
+		"TEMP", "TEMPORARY", "THEN", "TRANSACTION", "TRIGGER", "TRUE", "UNION", "UNIQUE", "UPDATE", "USING", "VALUES", "VIEW",
+		"WHEN", "WHERE", "WINDOW", "WITH", "WITHOUT",
+	)
+
+	// keywords that start a new line when formatting (outside parentheses)
+	sqlClauses = toSet("CROSS", "DELETE", "EXCEPT", "FROM", "FULL", "GROUP", "HAVING", "INNER", "INSERT", "INTERSECT", "JOIN",
+		"LEFT", "LIMIT", "NATURAL", "OFFSET", "ORDER", "RETURNING", "RIGHT", "SELECT", "SET", "UNION", "UPDATE", "VALUES",
+		"WHERE", "WINDOW",
+	)
+
+	// keywords that do not start a new line after particular keywords
+	sqlClauseContinues = map[string]map[string]bool{
+		"FROM": toSet("DELETE"),
+		"JOIN": toSet("CROSS", "FULL", "INNER", "LEFT", "NATURAL", "OUTER", "RIGHT"),
+	}
+
+	// statements that return a result set, even if empty
+	sqlQueries = toSet("EXPLAIN", "PRAGMA", "SELECT", "VALUES", "WITH")
+
+	// statements that could access files or load extensions, which are not supported
+	sqlUnsupported = toSet("ATTACH", "DETACH", "VACUUM")
+
+	// a schema block that seeds the database, which is a block comment
+	// starting with `schema`, such as `/* schema create table t(a); */`
+	sqlSchemaBlock = regexp.MustCompile("(?is)^/\\*\\s*schema\\b(.*)\\*/$")
+
+	// an error of SQLite, whose message follows the description
+	// of its code and is followed by the number of its code,
+	// such as `SQL logic error: no such table: t (1)`
+	sqliteError = regexp.MustCompile("(?s)^[^:]+: (.+) \\(\\d+\\)$")
+)
+
+// Gets a set from a list of strings.
+func toSet(values ...string) map[string]bool {
+	set := make(map[string]bool)
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// The kinds of SQL tokens.
+type sqlTokenKind int
+
+const (
+	sqlWord sqlTokenKind = iota
+	sqlString
+	sqlLineComment
+	sqlBlockComment
+	sqlSpace
+	sqlSymbol
+)
+
+// Represents a SQL token.
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// Splits SQL into tokens. Strings, quoted identifiers and
+// dollar-quoted strings are single tokens, as are comments.
+func tokenizeSQL(s string) (tokens []sqlToken) {
+	for len(s) > 0 {
+		kind, size := sqlSymbol, 0
+		r, rSize := utf8.DecodeRuneInString(s)
+		switch {
+		case unicode.IsSpace(r):
+			kind, size = sqlSpace, len(s)-len(strings.TrimLeftFunc(s, unicode.IsSpace))
+		case strings.HasPrefix(s, "--"):
+			kind, size = sqlLineComment, indexOrEnd(s, "\n", 0)
+		case strings.HasPrefix(s, "/*"):
+			kind, size = sqlBlockComment, indexOrEnd(s, "*/", 2)+2
+		case r == '\'' || r == '"' || r == '`':
+			// quotes are escaped by doubling them
+			size = 1
+			for size < len(s) {
+				size += indexOrEnd(s[size:], string(r), 0) + 1
+				if size >= len(s) || s[size] != byte(r) {
+					break
+				}
+				size++
+			}
+			kind = sqlString
+		case r == '$' && dollarTag(s) != "":
+			tag := dollarTag(s)
+			kind, size = sqlString, len(tag)+indexOrEnd(s[len(tag):], tag, 0)+len(tag)
+		case isSQLWordRune(r):
+			kind, size = sqlWord, len(s)-len(strings.TrimLeftFunc(s, isSQLWordRune))
+		default:
+			size = rSize
+		}
+		if size > len(s) {
+			size = len(s)
+		}
+		tokens = append(tokens, sqlToken{kind, s[:size]})
+		s = s[size:]
+	}
+	return
+}
+
+// Gets the index of a substring after an offset,
+// or the length of the string if not found.
+func indexOrEnd(s, substr string, offset int) int {
+	if offset > len(s) {
+		return len(s)
+	}
+	if i := strings.Index(s[offset:], substr); i != -1 {
+		return offset + i
+	}
+	return len(s)
+}
+
+// Gets the tag of a dollar-quoted string (e.g. `$$` or `$body$`)
+// at the start of the string, or empty if there is none.
+func dollarTag(s string) string {
+	end := strings.IndexByte(s[1:], '$')
+	if end == -1 {
+		return ""
+	}
+	tag := s[:end+2]
+	for i, r := range tag[1 : len(tag)-1] {
+		if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return ""
+		}
+	}
+	return tag
+}
+
+// Checks if a rune is part of a SQL word (keyword, identifier, number, or parameter).
+func isSQLWordRune(r rune) bool {
+	return r == '_' || r == '$' || r == '@' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// FormatSQL formats SQL by normalizing whitespace, uppercasing keywords,
+// and starting each clause (outside of parentheses) on a new line.
+// Comments are kept, and statements are separated by blank lines.
+func FormatSQL(text string, opts Options) (string, error) {
+	var b strings.Builder
+	var depth int
+	var prevWord string
+	var space, lineStart, between bool
+	lineStart = true
+
+	newline := func(indent string) {
+		if !lineStart {
+			b.WriteString("\n")
+		}
+		b.WriteString(indent)
+		lineStart, space = true, false
+	}
+	write := func(s string) {
+		if space && !lineStart {
+			b.WriteString(" ")
+		}
+		b.WriteString(s)
+		lineStart, space = false, false
+	}
+
+	tokens := tokenizeSQL(text)
+	for i, t := range tokens {
+		switch t.kind {
+		case sqlSpace:
+			space = true
+		case sqlLineComment:
+			write(strings.TrimRight(t.text, " \t\r"))
+			newline("")
+		case sqlWord:
+			upper := strings.ToUpper(t.text)
+			if sqlKeywords[upper] {
+				t.text = upper
+			}
+			if depth == 0 && sqlClauses[upper] && !sqlClauseContinues[upper][prevWord] {
+				newline("")
+			} else if depth == 0 && (upper == "AND" || upper == "OR") && !(upper == "AND" && between) {
+				newline("  ")
+			}
+			if upper == "BETWEEN" || upper == "AND" {
+				between = upper == "BETWEEN"
+			}
+			write(t.text)
+			prevWord = upper
+		case sqlSymbol:
+			switch t.text {
+			case "(":
+				depth++
+			case ")":
+				if depth > 0 {
+					depth--
+				}
+			case ";":
+				space = false
+				write(";")
+				depth, prevWord = 0, ""
+				if hasSQL(tokens[i+1:]) {
+					newline("")
+					b.WriteString("\n")
+				}
+				continue
+			}
+			write(t.text)
+		default:
+			write(t.text)
+		}
+	}
+	if !lineStart {
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// Checks if there are any tokens besides spaces and comments.
+func hasSQL(tokens []sqlToken) bool {
+	for _, t := range tokens {
+		if t.kind != sqlSpace && t.kind != sqlLineComment && t.kind != sqlBlockComment {
+			return true
+		}
+	}
+	return false
+}
+
+// Represents a SQL statement and the (one-based) line it starts on.
+type sqlStatement struct {
+	text string
+	line int
+}
+
+// Splits SQL into statements on semicolons,
+// ignoring statements that are only spaces and comments.
+func splitSQL(text string) (statements []sqlStatement) {
+	var b strings.Builder
+	var current []sqlToken
+	line := 1 // line of the start of the current statement
+	for _, t := range tokenizeSQL(text) {
+		if b.Len() == 0 && t.kind == sqlSpace {
+			line += strings.Count(t.text, "\n")
+			continue
+		}
+		current = append(current, t)
+		b.WriteString(t.text)
+		if t.kind == sqlSymbol && t.text == ";" {
+			if hasSQL(current[:len(current)-1]) {
+				statements = append(statements, sqlStatement{strings.TrimSpace(b.String()), line})
+			}
+			line += strings.Count(b.String(), "\n")
+			b.Reset()
+			current = nil
+		}
+	}
+	if hasSQL(current) {
+		statements = append(statements, sqlStatement{strings.TrimSpace(b.String()) + ";", line})
+	}
+	return
+}
+
+// Gets the first word of a statement, uppercased.
+func firstSQLWord(statement string) string {
+	for _, t := range tokenizeSQL(statement) {
+		switch t.kind {
+		case sqlSpace, sqlLineComment, sqlBlockComment:
+			continue
+		case sqlWord:
+			return strings.ToUpper(t.text)
+		}
+		return t.text
+	}
+	return ""
+}
+
+// RunSQL executes SQL statements against an embedded in-memory SQLite database,
+// and renders each result set as an aligned text table. The database is optionally
+// seeded from the schema blocks of the program (`/* schema ... */`), then from
+// a fixture file. It stops at the first statement that fails.
+func RunSQL(program string, opts Options) (*RunResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), RunTimeout)
+	defer cancel()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1) // each connection has its own in-memory database
+
+	// seed the database
+	var seeds []sqlStatement
+	for _, block := range getSQLSchemaBlocks(program) {
+		seeds = append(seeds, block...)
+	}
+	if opts.Fixture != "" {
+		fixture, err := ioutil.ReadFile(filepath.Join(FixtureDir, opts.Fixture+".sql"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture `%s`: %v", opts.Fixture, err)
+		}
+		for _, s := range splitSQL(string(fixture)) {
+			seeds = append(seeds, sqlStatement{s.text, 0})
+		}
+	}
+	for _, s := range seeds {
+		if _, err = db.ExecContext(ctx, s.text); err != nil {
+			return getSQLFailure(ctx, "", s, opts, err), nil
+		}
+	}
+
+	var tables []string
+	for _, s := range splitSQL(program) {
+		table, err := runSQLStatement(ctx, db, s)
+		if err != nil {
+			return getSQLFailure(ctx, strings.Join(tables, "\n"), s, opts, err), nil
+		}
+		if table != "" {
+			tables = append(tables, table)
+		}
+	}
+	return &RunResult{Output: strings.Join(tables, "\n")}, nil
+}
+
+// Gets the statements of the schema blocks of a program, which are block comments
+// starting with `schema`, at the lines of the program.
+func getSQLSchemaBlocks(program string) (blocks [][]sqlStatement) {
+	line := 1
+	for _, t := range tokenizeSQL(program) {
+		if t.kind == sqlBlockComment {
+			if sub := sqlSchemaBlock.FindStringSubmatch(t.text); sub != nil {
+				// the line of the block's SQL, after its start
+				start := line + strings.Count(t.text[:len(t.text)-len(sub[1])-2], "\n")
+				statements := splitSQL(sub[1])
+				for i := range statements {
+					statements[i].line += start - 1
+				}
+				blocks = append(blocks, statements)
+			}
+		}
+		line += strings.Count(t.text, "\n")
+	}
+	return
+}
+
+// Runs a statement, rendering its result set as a table. Statements
+// that could access files or extensions are not supported.
+func runSQLStatement(ctx context.Context, db *sql.DB, s sqlStatement) (string, error) {
+	word := firstSQLWord(s.text)
+	if strings.HasPrefix(word, ".") {
+		return "", errors.New("dot-commands are not supported")
+	}
+	if sqlUnsupported[word] {
+		return "", fmt.Errorf("%s is not supported", word)
+	}
+
+	rows, err := db.QueryContext(ctx, s.text)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return "", err
+	}
+
+	var values [][]string
+	for rows.Next() {
+		row := make([]interface{}, len(columns))
+		for i := range row {
+			row[i] = new(interface{})
+		}
+		if err = rows.Scan(row...); err != nil {
+			return "", err
+		}
+		cells := make([]string, len(columns))
+		for i, v := range row {
+			cells[i] = formatSQLValue(*v.(*interface{}), types[i].DatabaseTypeName())
+		}
+		values = append(values, cells)
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+	return renderSQLTable(columns, values, sqlQueries[word]), nil
+}
+
+// Gets the failed result of a statement, with the output of the statements before it.
+// The error is on the line of the statement in the program, unless it is a seed.
+func getSQLFailure(ctx context.Context, output string, s sqlStatement, opts Options, err error) *RunResult {
+	if ctx.Err() == context.DeadlineExceeded {
+		return &RunResult{Output: output, Errors: fmt.Sprintf("timeout running program (%v)", RunTimeout), Status: -1}
+	}
+	errs := fmt.Sprintf("line %d: %s", s.line, getSQLiteMessage(err))
+	if s.line == 0 {
+		errs = fmt.Sprintf("fixture `%s`: %s", opts.Fixture, getSQLiteMessage(err))
+	}
+	return &RunResult{Output: output, Errors: errs, Status: 1}
+}
+
+// Formats a value of a result set like `sqlite3` does, where a time
+// is a value of a column declared as a date, time or timestamp.
+func formatSQLValue(v interface{}, declType string) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	case float64:
+		// a real has a decimal point, even if it is an integer
+		f := strconv.FormatFloat(v, 'g', 15, 64)
+		if !strings.ContainsAny(f, ".IN") {
+			if e := strings.IndexByte(f, 'e'); e >= 0 {
+				return f[:e] + ".0" + f[e:]
+			}
+			return f + ".0"
+		}
+		return f
+	case time.Time:
+		if strings.EqualFold(declType, "date") {
+			return v.Format("2006-01-02")
+		}
+		return v.Format("2006-01-02 15:04:05.999999999")
+	}
+	return fmt.Sprint(v)
+}
+
+// Gets the message of a SQLite error, without
+// the description and number of its code.
+func getSQLiteMessage(err error) string {
+	if sub := sqliteError.FindStringSubmatch(err.Error()); sub != nil {
+		return sub[1]
+	}
+	return err.Error()
+}
+
+// Renders a result set as an aligned text table with a header,
+// escaping newlines in values. An empty result set is only rendered for queries.
+func renderSQLTable(columns []string, values [][]string, query bool) string {
+	if len(values) == 0 {
+		if query {
+			return "(0 rows)\n"
+		}
+		return ""
+	}
+	rows := append([][]string{columns}, values...)
+
+	// get column widths, escaping newlines in values
+	widths := make([]int, len(columns))
+	for _, row := range rows {
+		for i, v := range row {
+			row[i] = strings.ReplaceAll(v, "\n", "\\n")
+			if w := utf8.RuneCountInString(row[i]); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i, row := range rows {
+		var cells []string
+		for j, v := range row {
+			cells = append(cells, v+strings.Repeat(" ", widths[j]-utf8.RuneCountInString(v)))
+		}
+		b.WriteString(strings.TrimRight(strings.Join(cells, " | "), " ") + "\n")
+
+		// separate the header
+		if i == 0 {
+			var lines []string
+			for _, w := range widths {
+				lines = append(lines, strings.Repeat("-", w))
+			}
+			b.WriteString(strings.Join(lines, "-+-") + "\n")
+		}
+	}
+	if n := len(values); n == 1 {
+		b.WriteString("(1 row)\n")
+	} else {
+		fmt.Fprintf(&b, "(%d rows)\n", n)
+	}
+	return b.String()
+}
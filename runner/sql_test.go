@@ -0,0 +1,218 @@
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatSQL(t *testing.T) {
+	tests := []struct {
+		program string
+		want    string
+	}{
+		{"SELECT 1", "SELECT 1\n"},
+		{
+			"select a,b from t where x between 1 and 2 and y=1 or z=2;",
+			"SELECT a,b\nFROM t\nWHERE x BETWEEN 1 AND 2\n  AND y=1\n  OR z=2;\n",
+		},
+		{
+			"create table t(a int primary key, b text); insert into t values(1,'a''b');",
+			"CREATE TABLE t(a int PRIMARY KEY, b text);\n\nINSERT INTO t\nVALUES(1,'a''b');\n",
+		},
+		{
+			"-- note\nselect 'from' from t; /* keep */",
+			"-- note\nSELECT 'from'\nFROM t; /* keep */\n",
+		},
+		{"delete from t inner join u", "DELETE FROM t\nINNER JOIN u\n"},
+	}
+	for _, tt := range tests {
+		if got := checkIdempotent(t, FormatSQL, tt.program, Options{}); got != tt.want {
+			t.Errorf("FormatSQL(%q) = %q, want %q", tt.program, got, tt.want)
+		}
+	}
+}
+
+func TestSplitSQL(t *testing.T) {
+	// a comment is part of the statement after it
+	program := "\n-- a comment;\nselect 1;\n\ninsert into t\nvalues (';');\nselect 2"
+	want := []sqlStatement{
+		{"-- a comment;\nselect 1;", 2},
+		{"insert into t\nvalues (';');", 5},
+		{"select 2;", 7},
+	}
+	if got := splitSQL(program); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitSQL() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTokenizeSQL(t *testing.T) {
+	program := "select $tag$a;b$tag$, 'it''s', \"q\"\"d\" /* c */ -- d"
+	var texts []string
+	for _, tok := range tokenizeSQL(program) {
+		if tok.kind != sqlSpace {
+			texts = append(texts, tok.text)
+		}
+	}
+	want := []string{"select", "$tag$a;b$tag$", ",", "'it''s'", ",", "\"q\"\"d\"", "/* c */", "-- d"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("tokenizeSQL() = %q, want %q", texts, want)
+	}
+}
+
+func TestRunSQL(t *testing.T) {
+	program := "create table t(a int, b text);\ninsert into t values (1, 'x'), (22, NULL);\nselect * from t;\nselect * from t where a > 100;\n\nselect nope from t;\nselect 1;\n"
+	res, err := RunSQL(program, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantOutput := "a  | b\n---+-----\n1  | x\n22 | NULL\n(2 rows)\n\n(0 rows)\n"
+	if res.Output != wantOutput {
+		t.Errorf("RunSQL() output = %q, want %q", res.Output, wantOutput)
+	}
+	// the line of the error is the line in the program, and the statements after it are not run
+	if want := "line 6: no such column: nope"; res.Status != 1 || res.Errors != want {
+		t.Errorf("RunSQL() = %+v, want errors %q", res, want)
+	}
+
+	for _, program := range []string{".tables", "attach 'x.db' as x;", "vacuum into 'x.db';"} {
+		res, err = RunSQL(program, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Status == 0 || !strings.Contains(res.Errors, "not supported") {
+			t.Errorf("RunSQL(%q) = %+v, want it to be unsupported", program, res)
+		}
+	}
+}
+
+func TestRunSQLValues(t *testing.T) {
+	program := "select null as n, 1 as i, 2.0 as r, 1.5 as f, 'a\nb' as s, x'41' as b;"
+	res, err := RunSQL(program, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "n    | i | r   | f   | s    | b\n-----+---+-----+-----+------+--\nNULL | 1 | 2.0 | 1.5 | a\\nb | A\n(1 row)\n"
+	if res.Output != want || res.Status != 0 {
+		t.Errorf("RunSQL() = %+v, want output %q", res, want)
+	}
+}
+
+func TestFormatSQLValue(t *testing.T) {
+	date := time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		value    interface{}
+		declType string
+		want     string
+	}{
+		{nil, "", "NULL"},
+		{int64(-3), "INT", "-3"},
+		{2.0, "", "2.0"},
+		{0.1, "", "0.1"},
+		{1e300, "", "1.0e+300"},
+		{1.5e-7, "", "1.5e-07"},
+		{[]byte("a"), "BLOB", "a"},
+		{"a", "TEXT", "a"},
+		{date, "DATETIME", "2024-01-02 10:30:00"},
+		{date, "date", "2024-01-02"},
+	}
+	for _, tt := range tests {
+		if got := formatSQLValue(tt.value, tt.declType); got != tt.want {
+			t.Errorf("formatSQLValue(%#v, %q) = %q, want %q", tt.value, tt.declType, got, tt.want)
+		}
+	}
+}
+
+func TestRunSQLSchemaBlocks(t *testing.T) {
+	program := "/* schema\ncreate table users(name text);\ninsert into users values ('ada');\n*/\nselect name from users;\n/* a comment */\n"
+	res, err := RunSQL(program, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the schema is not output
+	if want := "name\n----\nada\n(1 row)\n"; res.Output != want || res.Status != 0 {
+		t.Errorf("RunSQL() = %+v, want output %q", res, want)
+	}
+
+	// the line of an error in the schema is its line in the program
+	res, err = RunSQL("select 1;\n/* SCHEMA\ncreate table t(a);\ncreate table t(a);\n*/", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != 1 || !strings.HasPrefix(res.Errors, "line 4: ") || !strings.Contains(res.Errors, "already exists") {
+		t.Errorf("RunSQL() = %+v, want the error on line 4", res)
+	}
+}
+
+func TestGetSQLSchemaBlocks(t *testing.T) {
+	program := "select '/* schema x; */';\n/*schema a;\n\nb; */ select 1; /* schemas c; */ /* schema\n  d; */"
+	var got []sqlStatement
+	for _, block := range getSQLSchemaBlocks(program) {
+		got = append(got, block...)
+	}
+	want := []sqlStatement{{"a;", 2}, {"b;", 4}, {"d;", 5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getSQLSchemaBlocks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRunSQLSeeds(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(fixtureDir string) {
+		FixtureDir = fixtureDir
+	}(FixtureDir)
+	FixtureDir = dir
+	fixtures := map[string]string{
+		"users.sql": "insert into users values ('ada'), ('bob')",
+		"bad.sql":   "insert into nope values (1);",
+	}
+	for name, fixture := range fixtures {
+		if err := ioutil.WriteFile(filepath.Join(FixtureDir, name), []byte(fixture), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the schema block creates the tables before the fixture fills them
+	program := "/* schema create table users(name text); */\nselect count(*) as n from users;"
+	res, err := RunSQL(program, Options{Fixture: "users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "n\n-\n2\n(1 row)\n"; res.Output != want || res.Status != 0 {
+		t.Errorf("RunSQL() = %+v, want output %q", res, want)
+	}
+
+	res, err = RunSQL("select 1;", Options{Fixture: "bad"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != 1 || !strings.HasPrefix(res.Errors, "fixture `bad`: ") {
+		t.Errorf("RunSQL() = %+v, want the error in the fixture", res)
+	}
+
+	if _, err = RunSQL("select 1;", Options{Fixture: "missing"}); err == nil || !strings.Contains(err.Error(), "fixture `missing`") {
+		t.Errorf("RunSQL() error = %v, want the missing fixture", err)
+	}
+}
+
+func TestRenderSQLTable(t *testing.T) {
+	got := renderSQLTable([]string{"id", "name"}, [][]string{{"1", "a\nb"}}, true)
+	if want := "id | name\n---+-----\n1  | a\\nb\n(1 row)\n"; got != want {
+		t.Errorf("renderSQLTable() = %q, want %q", got, want)
+	}
+
+	for _, query := range []bool{true, false} {
+		got := renderSQLTable(nil, nil, query)
+		if want := map[bool]string{true: "(0 rows)\n", false: ""}[query]; got != want {
+			t.Errorf("renderSQLTable(query=%v) = %q, want %q", query, got, want)
+		}
+	}
+}
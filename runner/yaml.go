@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	yamlSource = "yaml" // prefix of the errors of the YAML decoder, such as `yaml: line 3: ...`
+)
+
+// FormatYAML re-indents YAML, keeping the order of the keys and the comments,
+// and returns an error with the line if it is invalid.
+func FormatYAML(text string, opts Options) (string, error) {
+	dec := yaml.NewDecoder(strings.NewReader(text))
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(len(jsonIndent))
+	var documents int
+	for {
+		var node yaml.Node
+		if err := dec.Decode(&node); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		if err := enc.Encode(&node); err != nil {
+			return "", err
+		}
+		documents++
+	}
+	if documents == 0 {
+		return text, nil // no documents (e.g. only comments), which can not be encoded
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// RunYAML validates YAML (which may have multiple documents), and if the options
+// have a schema, validates the data against the schema. Problems are the errors of the result.
+func RunYAML(program string, opts Options) (*RunResult, error) {
+	dec := yaml.NewDecoder(strings.NewReader(program))
+	var documents []interface{}
+	for {
+		var data interface{}
+		if err := dec.Decode(&data); err == io.EOF {
+			break
+		} else if err != nil {
+			return &RunResult{Errors: err.Error(), Status: 1}, nil
+		}
+		documents = append(documents, data)
+	}
+	if len(documents) == 0 {
+		return &RunResult{Errors: "no YAML documents", Status: 1}, nil
+	}
+	return validateSchema("YAML", documents, opts.Schema)
+}
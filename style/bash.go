@@ -0,0 +1,99 @@
+package style
+
+import (
+	"GDocs-Syntax-Highlighter/runner"
+	"regexp"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// Note that some of the following Bash regexes are taken/inspired from the VSCode language files found here:
+	// https://github.com/microsoft/vscode/tree/master/extensions/shellscript/syntaxes
+	bash1 = regexp.MustCompile("\\b(break|case|continue|do|done|elif|else|esac|exit|fi|for|if|in|return|select|then|until|while)\\b")
+	bash2 = regexp.MustCompile("\\b(declare|export|function|local|readonly|typeset|unset)\\b|\\B-(eq|ne|lt|le|gt|ge|z|n|e|f|d)\\b")
+	bash3 = regexp.MustCompile("\\b(alias|cd|command|echo|eval|exec|getopts|kill|let|printf|pwd|read|set|shift|source|test|trap|type|wait)\\b")
+	bash4 = regexp.MustCompile("\\b\\d+\\b")
+
+	// A heredoc ends at a line that is only its delimiter. Its body expands
+	// variables unless the delimiter is quoted, and `<<-` allows the
+	// delimiter line to be indented with tabs.
+	bashHeredoc         = regexp.MustCompile("^<<\\s*([A-Za-z_]\\w*)")
+	bashQuotedHeredoc   = regexp.MustCompile("^<<\\s*['\"]([A-Za-z_]\\w*)['\"]")
+	bashIndentedHeredoc = regexp.MustCompile("^<<-\\s*['\"]?([A-Za-z_]\\w*)['\"]?")
+	bashHeredocFollows  = regexp.MustCompile("(^|[^<])$") // not a here-string (`<<<`)
+
+	// A comment starts at a word, so `$#` and `a#b` are not comments.
+	bashCommentFollows = regexp.MustCompile("(^|[\\s;&|()])$")
+	bashVariable       = regexp.MustCompile("^\\$([A-Za-z_]\\w*|[0-9@*#?$!-])")
+	bashEscape         = regexp.MustCompile("^\\\\.")
+
+	// Command substitutions inside strings and heredocs are code.
+	bashCommand  = &Interpolation{StartSymbol: "$(", EndSymbol: ")", Open: "("}
+	bashBacktick = &Interpolation{StartSymbol: "`", EndSymbol: "`"}
+
+	// heuristics to detect Bash
+	bashBlockEnd  = regexp.MustCompile("(?m)[;\\s](fi|done|esac)\\s*$")
+	bashTest      = regexp.MustCompile("\\[\\[?\\s[^\\]\\n]*\\s\\]\\]?")
+	bashEcho      = regexp.MustCompile("(?m)^\\s*(echo|export|source)\\s")
+	bashDetection = &Detection{
+		Interpreters: []string{"bash", "sh", "zsh", "dash", "ksh"},
+		Heuristics:   []*regexp.Regexp{bashBlockEnd, bashTest, bashEcho},
+		Keywords:     []*regexp.Regexp{bash1, bash3},
+	}
+
+	bashMainShortcut = &Shortcut{
+		regexp.MustCompile("\\*\\*main\\*\\*"),
+		"#!/bin/bash\n\necho \"hello world\"\n",
+	}
+)
+
+// Gets the Bash ranges for particular colors.
+func getBashRanges(comment, str, variable *docs.Color) []*Range {
+	variables := []*Range{
+		{StartSymbol: "${", EndSymbol: "}", Color: variable, Nested: true},
+		{Pattern: bashVariable, Color: variable},
+	}
+	expansions := []*Interpolation{bashCommand, bashBacktick}
+	return append([]*Range{
+		{StartPattern: bashIndentedHeredoc, EndSymbol: "$1\n", Color: str, Follows: bashHeredocFollows, Interpolations: expansions, Inner: variables},
+		{StartPattern: bashQuotedHeredoc, EndSymbol: "\n$1\n", Color: str, Follows: bashHeredocFollows},
+		{StartPattern: bashHeredoc, EndSymbol: "\n$1\n", Color: str, Follows: bashHeredocFollows, Interpolations: expansions, Inner: variables},
+		{StartSymbol: "#", EndSymbol: "\n", Color: comment, Follows: bashCommentFollows},
+		{Pattern: bashEscape, Color: str},
+		{StartSymbol: "$'", EndSymbol: "'", Color: str, Escape: "\\"},
+		{StartSymbol: "'", EndSymbol: "'", Color: str},
+		{StartSymbol: "\"", EndSymbol: "\"", Color: str, Escape: "\\", Interpolations: expansions, Inner: variables},
+	}, variables...)
+}
+
+var (
+	bashLang = &Language{
+		Name:      "Bash",
+		Format:    runner.FormatBash,
+		Run:       runner.RunBash,
+		Lint:      runner.LintBash,
+		Detect:    bashDetection,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes, bashMainShortcut},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getBashRanges(DarkThemeDarkGreen, DarkThemeLightRedOrange, DarkThemeLightBlue),
+				[]Keyword{
+					{Regex: bash1, Color: DarkThemePink},
+					{Regex: bash2, Color: DarkThemeDarkBlue},
+					{Regex: bash3, Color: DarkThemeYellow},
+					{Regex: bash4, Color: DarkThemePaleGreen},
+				},
+			),
+			lightTheme: getLightTheme(
+				getBashRanges(LightThemeDarkGreen, LightThemeDarkRed, LightThemeNavy),
+				[]Keyword{
+					{Regex: bash1, Color: LightThemePink},
+					{Regex: bash2, Color: Blue},
+					{Regex: bash3, Color: LightThemeStrawYellow},
+					{Regex: bash4, Color: LightThemePaleGreen},
+				},
+			),
+		},
+	}
+)
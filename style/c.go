@@ -0,0 +1,149 @@
+package style
+
+import (
+	"GDocs-Syntax-Highlighter/runner"
+	"regexp"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// Note that some of the following C/C++ regexes are taken/inspired from the VSCode language files found here:
+	// https://github.com/microsoft/vscode/tree/master/extensions/cpp/syntaxes
+	c1   = regexp.MustCompile("\\b(break|case|continue|default|do|else|for|goto|if|return|switch|while)\\b")
+	c2   = regexp.MustCompile("\\b(auto|const|enum|extern|false|inline|NULL|register|restrict|signed|sizeof|static|struct|true|typedef|union|unsigned|volatile|_Alignas|_Alignof|_Atomic|_Generic|_Noreturn|_Static_assert|_Thread_local)\\b")
+	c3   = regexp.MustCompile("\\b(bool|char|double|float|int|long|short|void|_Bool|FILE|ptrdiff_t|s?size_t|u?int(8|16|32|64)_t)\\b")
+	c4   = regexp.MustCompile("\\b(abort|assert|calloc|exit|fprintf|free|getchar|malloc|memcpy|memset|printf|putchar|puts|realloc|scanf|snprintf|sprintf|strcmp|strcpy|strlen)\\b")
+	c5   = regexp.MustCompile("\\b(0[xX][\\da-fA-F']+|0[bB][01']+|\\d[\\d']*(\\.\\d*)?([eE][+-]?\\d+)?)[uUlLfF]*\\b")
+	cpp1 = regexp.MustCompile("\\b(catch|co_await|co_return|co_yield|throw|try)\\b")
+	cpp2 = regexp.MustCompile("\\b(class|concept|const_cast|consteval|constexpr|constinit|decltype|delete|dynamic_cast|explicit|final|friend|mutable|namespace|new|noexcept|nullptr|operator|override|private|protected|public|reinterpret_cast|requires|static_cast|template|this|typename|using|virtual)\\b")
+	cpp3 = regexp.MustCompile("\\b(char8_t|char16_t|char32_t|map|set|shared_ptr|std|string|unique_ptr|unordered_map|vector|wchar_t)\\b")
+	cpp4 = regexp.MustCompile("\\b(cerr|cin|cout|endl|forward|make_shared|make_unique|move)\\b")
+
+	// A preprocessor directive must start its line, and a header
+	// name in angle brackets must follow an include directive.
+	cDirectiveFollows = regexp.MustCompile("^\\s*$")
+	cDirective        = regexp.MustCompile("^#\\s*(define|elif|elifdef|elifndef|else|endif|error|if|ifdef|ifndef|include|line|pragma|undef|warning)\\b")
+	cHeaderFollows    = regexp.MustCompile("#\\s*include\\s*$")
+	cHeader           = regexp.MustCompile("^<[^<>\\n]*>")
+
+	// A C++ raw string (e.g. `R"x(...)x"`) ends with its delimiter.
+	cppRawString = regexp.MustCompile("^(u8|[uUL])?R\"([^()\\\\\\s]{0,16})\\(")
+
+	// An escape sequence inside a string or character.
+	cEscape = regexp.MustCompile("^\\\\([abfnrtv\\\\'\"?]|x[0-9a-fA-F]+|u[0-9a-fA-F]{4}|U[0-9a-fA-F]{8}|[0-7]{1,3})")
+
+	// heuristics to detect C/C++
+	cInclude   = regexp.MustCompile("(?m)^#\\s*include\\s*<\\w+\\.h>")
+	cMain      = regexp.MustCompile("\\bint\\s+main\\s*\\(")
+	cPrintf    = regexp.MustCompile("\\b(printf|scanf|malloc|free)\\s*\\(")
+	cppInclude = regexp.MustCompile("(?m)^#\\s*include\\s*<\\w+>")
+	cppStd     = regexp.MustCompile("\\bstd::|\\bc(out|in)\\s*(<<|>>)")
+	cppClass   = regexp.MustCompile("\\b(class|namespace|template)\\s*[\\w<]")
+	cDetection = &Detection{
+		Heuristics: []*regexp.Regexp{cInclude, cMain, cPrintf},
+		Keywords:   []*regexp.Regexp{c1, c2, c3},
+	}
+	cppDetection = &Detection{
+		Heuristics: []*regexp.Regexp{cppInclude, cMain, cppStd, cppClass},
+		Keywords:   []*regexp.Regexp{c1, cpp1, cpp2, cpp3},
+	}
+
+	cMainShortcut = &Shortcut{
+		regexp.MustCompile("\\*\\*main\\*\\*"),
+		"#include <stdio.h>\n\nint main(void) {\n\tprintf(\"hello world\\n\");\n\treturn 0;\n}\n",
+	}
+	cppMainShortcut = &Shortcut{
+		regexp.MustCompile("\\*\\*main\\*\\*"),
+		"#include <iostream>\n\nint main() {\n\tstd::cout << \"hello world\" << std::endl;\n\treturn 0;\n}\n",
+	}
+)
+
+// Gets the C/C++ ranges for particular colors.
+// Block comments do not nest, so `/* /* */` is a single comment,
+// and line comments continue onto the next line after a `\`.
+func getCRanges(comment, str, escape, directive *docs.Color, cpp bool) []*Range {
+	escapes := []*Range{{Pattern: cEscape, Color: escape}}
+	ranges := []*Range{
+		{StartSymbol: "//", EndSymbol: "\n", Color: comment, Escape: "\\"},
+		{StartSymbol: "/*", EndSymbol: "*/", Color: comment},
+		{Pattern: cDirective, Follows: cDirectiveFollows, Color: directive},
+		{Pattern: cHeader, Follows: cHeaderFollows, Color: str},
+	}
+	if cpp {
+		ranges = append(ranges, &Range{StartPattern: cppRawString, EndSymbol: ")$2\"", Color: str})
+	}
+	return append(ranges,
+		&Range{StartSymbol: "\"", EndSymbol: "\"", Color: str, Escape: "\\", SingleLine: true, Inner: escapes},
+		&Range{StartSymbol: "'", EndSymbol: "'", Color: str, Escape: "\\", SingleLine: true, Inner: escapes},
+	)
+}
+
+var (
+	cLang = &Language{
+		Name:      "C",
+		Format:    runner.FormatC,
+		Run:       runner.RunC,
+		Detect:    cDetection,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes, cMainShortcut},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getCRanges(DarkThemeDarkGreen, DarkThemeLightRedOrange, DarkThemeStrawYellow, DarkThemePink, false),
+				[]Keyword{
+					{Regex: c1, Color: DarkThemePink},
+					{Regex: c2, Color: DarkThemeDarkBlue},
+					{Regex: c3, Color: DarkThemeGreenCyan},
+					{Regex: c4, Color: DarkThemeYellow},
+					{Regex: c5, Color: DarkThemePaleGreen},
+				},
+			),
+			lightTheme: getLightTheme(
+				getCRanges(LightThemeDarkGreen, LightThemeDarkRed, LightThemeBrightRed, LightThemePink, false),
+				[]Keyword{
+					{Regex: c1, Color: LightThemePink},
+					{Regex: c2, Color: Blue},
+					{Regex: c3, Color: LightThemeGreenCyan},
+					{Regex: c4, Color: LightThemeStrawYellow},
+					{Regex: c5, Color: LightThemePaleGreen},
+				},
+			),
+		},
+	}
+	cppLang = &Language{
+		Name:      "C++",
+		Format:    runner.FormatCpp,
+		Run:       runner.RunCpp,
+		Detect:    cppDetection,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes, cppMainShortcut},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getCRanges(DarkThemeDarkGreen, DarkThemeLightRedOrange, DarkThemeStrawYellow, DarkThemePink, true),
+				[]Keyword{
+					{Regex: c1, Color: DarkThemePink},
+					{Regex: cpp1, Color: DarkThemePink},
+					{Regex: c2, Color: DarkThemeDarkBlue},
+					{Regex: cpp2, Color: DarkThemeDarkBlue},
+					{Regex: c3, Color: DarkThemeGreenCyan},
+					{Regex: cpp3, Color: DarkThemeGreenCyan},
+					{Regex: c4, Color: DarkThemeYellow},
+					{Regex: cpp4, Color: DarkThemeYellow},
+					{Regex: c5, Color: DarkThemePaleGreen},
+				},
+			),
+			lightTheme: getLightTheme(
+				getCRanges(LightThemeDarkGreen, LightThemeDarkRed, LightThemeBrightRed, LightThemePink, true),
+				[]Keyword{
+					{Regex: c1, Color: LightThemePink},
+					{Regex: cpp1, Color: LightThemePink},
+					{Regex: c2, Color: Blue},
+					{Regex: cpp2, Color: Blue},
+					{Regex: c3, Color: LightThemeGreenCyan},
+					{Regex: cpp3, Color: LightThemeGreenCyan},
+					{Regex: c4, Color: LightThemeStrawYellow},
+					{Regex: cpp4, Color: LightThemeStrawYellow},
+					{Regex: c5, Color: LightThemePaleGreen},
+				},
+			),
+		},
+	}
+)
@@ -44,6 +44,51 @@ var (
 	// LightThemeDarkRed is VSCode's light theme dark red color.
 	LightThemeDarkRed = getColorFromHex("A31515")
 
+	// LightThemeMaroon is VSCode's light theme maroon color.
+	LightThemeMaroon = getColorFromHex("800000")
+
+	// LightThemeDarkMaroon is VSCode's light theme dark maroon color.
+	LightThemeDarkMaroon = getColorFromHex("811F3F")
+
+	// LightThemeNavy is VSCode's light theme navy color.
+	LightThemeNavy = getColorFromHex("001080")
+
+	// LightThemeRed is VSCode's light theme red color.
+	LightThemeRed = getColorFromHex("E50000")
+
+	// LightThemeBrightRed is VSCode's light theme bright red color.
+	LightThemeBrightRed = getColorFromHex("EE0000")
+
+	// LightThemeCobalt is VSCode's light theme cobalt blue color.
+	LightThemeCobalt = getColorFromHex("0451A5")
+
+	// LightThemeBracketBlue is VSCode's light theme blue color of brackets.
+	LightThemeBracketBlue = getColorFromHex("0431FA")
+
+	// LightThemeBracketGreen is VSCode's light theme green color of brackets.
+	LightThemeBracketGreen = getColorFromHex("319331")
+
+	// LightThemeBracketBrown is VSCode's light theme brown color of brackets.
+	LightThemeBracketBrown = getColorFromHex("7B3814")
+
+	// LightThemeLineNumber is VSCode's light theme line number color (teal).
+	LightThemeLineNumber = getColorFromHex("237893")
+
+	// LightThemeLineHighlight is VSCode's light theme inactive selection color (pale blue).
+	LightThemeLineHighlight = getColorFromHex("E5EBF1")
+
+	// LightThemeDiffAdded is VSCode's light theme diff inserted text color (pale green).
+	LightThemeDiffAdded = getColorFromHex("E6F2CA")
+
+	// LightThemeDiffRemoved is VSCode's light theme diff removed text color (pale red).
+	LightThemeDiffRemoved = getColorFromHex("FFCCCC")
+
+	// LightThemeErrorBackground is VSCode's light theme error background color (pale red).
+	LightThemeErrorBackground = getColorFromHex("F2DEDE")
+
+	// LightThemeWarningBackground is VSCode's light theme warning background color (pale yellow).
+	LightThemeWarningBackground = getColorFromHex("F6F5D2")
+
 	// DarkThemeBackground is VSCode's dark theme background color (dark gray).
 	DarkThemeBackground = getColorFromHex("1E1E1E")
 
@@ -71,6 +116,9 @@ var (
 	// DarkThemeBlue is VSCode's dark theme blue color.
 	DarkThemeBlue = getColorFromHex("4FC1FF")
 
+	// DarkThemeCornflowerBlue is VSCode's dark theme cornflower blue color.
+	DarkThemeCornflowerBlue = getColorFromHex("6796E6")
+
 	// DarkThemeDarkBlue is VSCode's dark theme dark blue color.
 	DarkThemeDarkBlue = getColorFromHex("569CD6")
 
@@ -82,6 +130,36 @@ var (
 
 	// DarkThemeStrawYellow is VSCode's dark theme straw-yellow color.
 	DarkThemeStrawYellow = getColorFromHex("D7BA7D")
+
+	// DarkThemeBracketGold is VSCode's dark theme gold color of brackets.
+	DarkThemeBracketGold = getColorFromHex("FFD700")
+
+	// DarkThemeBracketOrchid is VSCode's dark theme orchid color of brackets.
+	DarkThemeBracketOrchid = getColorFromHex("DA70D6")
+
+	// DarkThemeBracketBlue is VSCode's dark theme blue color of brackets.
+	DarkThemeBracketBlue = getColorFromHex("179FFF")
+
+	// DarkThemeLineNumber is VSCode's dark theme line number color (gray).
+	DarkThemeLineNumber = getColorFromHex("858585")
+
+	// DarkThemeLineHighlight is VSCode's dark theme inactive selection color (gray).
+	DarkThemeLineHighlight = getColorFromHex("3A3D41")
+
+	// DarkThemeDiffAdded is VSCode's dark theme diff inserted text color (dark green).
+	DarkThemeDiffAdded = getColorFromHex("373D29")
+
+	// DarkThemeDiffRemoved is VSCode's dark theme diff removed text color (dark red).
+	DarkThemeDiffRemoved = getColorFromHex("4B1818")
+
+	// UnexpectedBracketRed is VSCode's color of unmatched brackets (bright red).
+	UnexpectedBracketRed = getColorFromHex("FF1212")
+
+	// DarkThemeErrorBackground is VSCode's dark theme error background color (dark red).
+	DarkThemeErrorBackground = getColorFromHex("5A1D1D")
+
+	// DarkThemeWarningBackground is VSCode's dark theme warning background color (dark yellow).
+	DarkThemeWarningBackground = getColorFromHex("352A05")
 )
 
 // Gets an RGB color from red, green, blue values in [0.0, 1.0].
@@ -0,0 +1,71 @@
+package style
+
+import (
+	"GDocs-Syntax-Highlighter/runner"
+	"regexp"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// Note that some of the following CSS regexes are taken/inspired from the VSCode language files found here:
+	// https://github.com/microsoft/vscode/tree/master/extensions/css/syntaxes
+	// Selectors are highlighted before numbers, so that a color (e.g. `#fff`) is a number.
+	css1 = regexp.MustCompile("@[\\w-]+|!important\\b")
+	css2 = regexp.MustCompile("\\b(attr|calc|clamp|hsla?|linear-gradient|max|min|rgba?|url|var)\\b")
+	css3 = regexp.MustCompile("[.#][A-Za-z_-][\\w-]*|::?[\\w-]+")
+	css4 = regexp.MustCompile("-?\\b\\d+(\\.\\d+)?(%|[a-z]+\\b)?|#[\\da-fA-F]{3,8}\\b")
+
+	// heuristics to detect CSS
+	cssSelector    = regexp.MustCompile("(?m)^\\s*[.#]?[\\w-]+([\\s,>+~:.#]+[\\w-]+)*\\s*\\{\\s*$")
+	cssDeclaration = regexp.MustCompile("(?m)^\\s*[\\w-]+\\s*:\\s*[^;{}()\\n]+;\\s*$")
+	cssDetection   = &Detection{
+		Heuristics: []*regexp.Regexp{cssSelector, cssDeclaration},
+		Keywords:   []*regexp.Regexp{css1},
+	}
+
+	// A property starts a declaration and is followed by a colon and its value,
+	// so `a:hover {` is a selector.
+	cssProperty         = regexp.MustCompile("^-*[A-Za-z][\\w-]*")
+	cssPropertyFollows  = regexp.MustCompile("(^|[{;])\\s*$")
+	cssPropertyPrecedes = regexp.MustCompile("^\\s*:[^{};]*[;}]")
+)
+
+// Gets the CSS ranges for particular colors.
+func getCSSRanges(comment, str, property *docs.Color) []*Range {
+	return []*Range{
+		{StartSymbol: "/*", EndSymbol: "*/", Color: comment},
+		{StartSymbol: "\"", EndSymbol: "\"", Color: str, Escape: "\\", SingleLine: true},
+		{StartSymbol: "'", EndSymbol: "'", Color: str, Escape: "\\", SingleLine: true},
+		{Pattern: cssProperty, Color: property, Follows: cssPropertyFollows, Precedes: cssPropertyPrecedes},
+	}
+}
+
+var (
+	cssLang = &Language{
+		Name:      "CSS",
+		Format:    runner.FormatCSS,
+		Detect:    cssDetection,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getCSSRanges(DarkThemeDarkGreen, DarkThemeLightRedOrange, DarkThemeLightBlue),
+				[]Keyword{
+					{Regex: css1, Color: DarkThemePink},
+					{Regex: css2, Color: DarkThemeYellow},
+					{Regex: css3, Color: DarkThemeStrawYellow},
+					{Regex: css4, Color: DarkThemePaleGreen},
+				},
+			),
+			lightTheme: getLightTheme(
+				getCSSRanges(LightThemeDarkGreen, LightThemeDarkRed, LightThemeRed),
+				[]Keyword{
+					{Regex: css1, Color: LightThemePink},
+					{Regex: css2, Color: LightThemeStrawYellow},
+					{Regex: css3, Color: LightThemeMaroon},
+					{Regex: css4, Color: LightThemePaleGreen},
+				},
+			),
+		},
+	}
+)
@@ -0,0 +1,340 @@
+package style
+
+import (
+	"GDocs-Syntax-Highlighter/runner"
+	"GDocs-Syntax-Highlighter/textmate"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/docs/v1"
+)
+
+const (
+	// LanguageDirEnv is the environment variable that
+	// overrides the default language definition directory.
+	LanguageDirEnv = "GDOCS_LANGUAGES"
+)
+
+var (
+	// LanguageDir is the directory of language definition files (`<name>.json`)
+	// that are registered when the bot starts.
+	// The bot defaults it to the directory inside the user config dir,
+	// unless $GDOCS_LANGUAGES is set.
+	LanguageDir = "languages"
+
+	// Gets the theme of each theme name for particular ranges and keywords.
+	themeFuncs = map[string]func([]*Range, []Keyword) *Theme{
+		darkTheme:  getDarkTheme,
+		lightTheme: getLightTheme,
+	}
+)
+
+// A language definition file declares a language without code, for example:
+//
+//	{
+//		"name": "Python",
+//		"aliases": ["py"],
+//		"format": {"command": "black", "args": ["-q", "-"]},
+//		"run": {"command": "python3", "file": "main.py"},
+//		"detect": {"interpreters": ["python3"], "heuristics": ["(?m)^def \\w+\\("]},
+//		"themes": {
+//			"light": {
+//				"ranges": [{"start": "#", "end": "\n", "color": "#008000"}],
+//				"keywords": [
+//					{"words": ["def", "return"], "color": "#0000FF"},
+//					{"pattern": "\\b\\d+(\\.\\d+)?\\b", "color": "#098658"},
+//					{"pattern": "\\bdef\\s+(\\w+)", "group": 1, "color": "#795E26"}
+//				]
+//			}
+//		}
+//	}
+//
+// A theme that is not declared has no ranges or keywords.
+//
+// Instead of ranges, the code can be highlighted by a TextMate grammar,
+// such as `"grammar": "python.tmLanguage.json"` (relative to the definition file),
+// where a theme's `"scopes"` such as `{"string.quoted": "#A31515"}`
+// add to or replace the theme's default colors of scopes.
+type languageDefinition struct {
+	Name    string                      `json:"name"`
+	Aliases []string                    `json:"aliases"`
+	Format  *runner.Command             `json:"format"` // reads the program from STDIN and writes it formatted to STDOUT
+	Run     *runner.Command             `json:"run"`    // runs the program's file
+	Detect  *detectionDefinition        `json:"detect"`
+	Grammar string                      `json:"grammar"`
+	Themes  map[string]*themeDefinition `json:"themes"`
+}
+
+type detectionDefinition struct {
+	Interpreters []string `json:"interpreters"`
+	Heuristics   []string `json:"heuristics"`
+	Keywords     []string `json:"keywords"`
+}
+
+type themeDefinition struct {
+	Ranges   []*rangeDefinition   `json:"ranges"`
+	Keywords []*keywordDefinition `json:"keywords"`
+	Scopes   map[string]string    `json:"scopes"`
+}
+
+// A range is either between start and end symbols, or a match of a pattern.
+type rangeDefinition struct {
+	Start      string `json:"start"`
+	End        string `json:"end"`
+	Escape     string `json:"escape"`
+	Nested     bool   `json:"nested"`
+	SingleLine bool   `json:"singleLine"`
+	Precedence int    `json:"precedence"`
+	Pattern    string `json:"pattern"`
+	Color      string `json:"color"`
+}
+
+// A keyword is either one of some words, or a match of a pattern (e.g. numbers),
+// where the color can highlight a capture group, and groups can have their own colors.
+type keywordDefinition struct {
+	Words   []string          `json:"words"`
+	Pattern string            `json:"pattern"`
+	Color   string            `json:"color"`
+	Group   int               `json:"group"`
+	Groups  map[string]string `json:"groups"` // e.g. `{"1": "#795E26"}`
+}
+
+// LoadLanguages registers the languages of the definition files
+// in a directory, returning them. A missing directory has no languages.
+func LoadLanguages(dir string) ([]*Language, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var langs []*Language
+	for _, path := range paths {
+		l, err := loadLanguage(path)
+		if err == nil {
+			err = Register(l)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		langs = append(langs, l)
+	}
+	return langs, nil
+}
+
+// Loads a language from a definition file.
+func loadLanguage(path string) (*Language, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var def languageDefinition
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&def); err != nil {
+		return nil, err
+	}
+
+	l := &Language{
+		Name:    def.Name,
+		Aliases: def.Aliases,
+		Themes:  make(map[string]*Theme),
+	}
+	if def.Format != nil {
+		l.Format = def.Format.Format
+	}
+	if def.Run != nil {
+		l.Run = def.Run.Run
+	}
+	if def.Detect != nil {
+		if l.Detect, err = def.Detect.getDetection(); err != nil {
+			return nil, err
+		}
+	}
+
+	var grammar *textmate.Grammar
+	if def.Grammar != "" {
+		grammarPath := def.Grammar
+		if !filepath.IsAbs(grammarPath) {
+			grammarPath = filepath.Join(filepath.Dir(path), grammarPath)
+		}
+		if grammar, err = textmate.Load(grammarPath); err != nil {
+			return nil, fmt.Errorf("grammar: %v", err)
+		}
+		// other grammars can embed it (e.g. in a Markdown grammar's code blocks)
+		textmate.Register(grammar)
+	}
+
+	for name := range def.Themes {
+		if _, ok := themes[name]; !ok {
+			return nil, fmt.Errorf("unknown theme `%s`", name)
+		}
+	}
+	for name, getTheme := range themeFuncs {
+		d := def.Themes[name]
+		if d == nil {
+			d = &themeDefinition{}
+		}
+		t, err := d.getTheme(getTheme)
+		if err != nil {
+			return nil, fmt.Errorf("%s theme: %v", name, err)
+		}
+		if grammar != nil {
+			t.Grammar = grammar
+			if t.Scopes, err = d.getScopes(defaultScopes[name]); err != nil {
+				return nil, fmt.Errorf("%s theme: %v", name, err)
+			}
+		}
+		l.Themes[name] = t
+	}
+	return l, nil
+}
+
+// Gets the detection of a definition.
+func (d *detectionDefinition) getDetection() (*Detection, error) {
+	heuristics, err := compileRegexes(d.Heuristics)
+	if err != nil {
+		return nil, err
+	}
+	keywords, err := compileRegexes(d.Keywords)
+	if err != nil {
+		return nil, err
+	}
+	return &Detection{
+		Interpreters: d.Interpreters,
+		Heuristics:   heuristics,
+		Keywords:     keywords,
+	}, nil
+}
+
+// Gets the theme of a definition from a theme func.
+func (d *themeDefinition) getTheme(getTheme func([]*Range, []Keyword) *Theme) (*Theme, error) {
+	var ranges []*Range
+	for _, r := range d.Ranges {
+		rng, err := r.getRange()
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, rng)
+	}
+
+	var keywords []Keyword
+	for _, k := range d.Keywords {
+		keyword, err := k.getKeyword()
+		if err != nil {
+			return nil, err
+		}
+		keywords = append(keywords, keyword)
+	}
+	return getTheme(ranges, keywords), nil
+}
+
+// Gets the colors of scopes of a definition, added to the default colors.
+func (d *themeDefinition) getScopes(defaults map[string]*docs.Color) (map[string]*docs.Color, error) {
+	scopes := make(map[string]*docs.Color)
+	for scope, color := range defaults {
+		scopes[scope] = color
+	}
+	for scope, color := range d.Scopes {
+		c, err := parseColor(color)
+		if err != nil {
+			return nil, err
+		}
+		scopes[scope] = c
+	}
+	return scopes, nil
+}
+
+// Gets the range of a definition.
+func (d *rangeDefinition) getRange() (*Range, error) {
+	color, err := parseColor(d.Color)
+	if err != nil {
+		return nil, err
+	}
+	r := &Range{
+		StartSymbol: d.Start,
+		EndSymbol:   d.End,
+		Color:       color,
+		Escape:      d.Escape,
+		Nested:      d.Nested,
+		SingleLine:  d.SingleLine,
+		Precedence:  d.Precedence,
+	}
+	if d.Pattern != "" {
+		if r.Pattern, err = regexp.Compile("^(?:" + d.Pattern + ")"); err != nil {
+			return nil, err
+		}
+	} else if d.Start == "" || d.End == "" {
+		return nil, fmt.Errorf("range needs start and end symbols, or a pattern")
+	}
+	return r, nil
+}
+
+// Gets the keyword of a definition.
+func (d *keywordDefinition) getKeyword() (Keyword, error) {
+	k := Keyword{Group: d.Group}
+	var err error
+	if d.Color != "" || len(d.Groups) == 0 {
+		if k.Color, err = parseColor(d.Color); err != nil {
+			return Keyword{}, err
+		}
+	}
+	if len(d.Groups) > 0 {
+		k.Groups = make(map[int]*docs.Color)
+		for group, color := range d.Groups {
+			i, err := strconv.Atoi(group)
+			if err != nil || i < 0 {
+				return Keyword{}, fmt.Errorf("invalid group `%s`", group)
+			}
+			if k.Groups[i], err = parseColor(color); err != nil {
+				return Keyword{}, err
+			}
+		}
+	}
+
+	pattern := d.Pattern
+	if len(d.Words) > 0 {
+		if pattern != "" {
+			return Keyword{}, fmt.Errorf("keyword needs words or a pattern, not both")
+		}
+		words := make([]string, len(d.Words))
+		for i, w := range d.Words {
+			words[i] = regexp.QuoteMeta(w)
+		}
+		pattern = "\\b(" + strings.Join(words, "|") + ")\\b"
+	} else if pattern == "" {
+		return Keyword{}, fmt.Errorf("keyword needs words or a pattern")
+	}
+
+	if k.Regex, err = regexp.Compile(pattern); err != nil {
+		return Keyword{}, err
+	}
+	return k, nil
+}
+
+// Compiles regexes, returning the first error.
+func compileRegexes(patterns []string) ([]*regexp.Regexp, error) {
+	regexes := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		var err error
+		if regexes[i], err = regexp.Compile(p); err != nil {
+			return nil, err
+		}
+	}
+	return regexes, nil
+}
+
+// Parses a color from a hex code, with or without a `#`.
+func parseColor(h string) (*docs.Color, error) {
+	h = strings.TrimPrefix(h, "#")
+	if b, err := hex.DecodeString(h); err != nil || len(b) != 3 {
+		return nil, fmt.Errorf("invalid color `%s`, expected a hex code like `#RRGGBB`", h)
+	}
+	return getColorFromHex(h), nil
+}
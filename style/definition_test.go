@@ -0,0 +1,162 @@
+package style
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Removes a registered language, so tests do not leak it to each other.
+func unregister(l *Language) {
+	delete(languages, getLanguageKey(l.Name))
+	for _, alias := range l.Aliases {
+		delete(languages, getLanguageKey(alias))
+	}
+}
+
+// Creates a temporary directory with language definition files,
+// which the test must remove.
+func writeDefinitions(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "languages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			os.RemoveAll(dir)
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadLanguages(t *testing.T) {
+	// the example definitions of the repository
+	langs, err := LoadLanguages(filepath.Join("..", "languages"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range langs {
+		defer unregister(l)
+	}
+	if len(langs) != 1 || langs[0].Name != "Python" {
+		t.Fatalf("LoadLanguages() = %v, want Python", langs)
+	}
+	python := langs[0]
+	for _, name := range []string{"python", "PY", "python3"} {
+		if l, ok := GetLanguage(name); !ok || l != python {
+			t.Errorf("GetLanguage(%q) = %v, %v, want Python", name, l, ok)
+		}
+	}
+	if python.Format == nil || python.Run == nil {
+		t.Error("Python has no format or run command")
+	}
+	if l := DetectLanguage("#!/usr/bin/env python3\nx = 1\n"); l != python {
+		t.Errorf("DetectLanguage() = %s, want Python", l.Name)
+	}
+	if l := DetectLanguage("import os\n\ndef main():\n    print(os.name)\n"); l != python {
+		t.Errorf("DetectLanguage() = %s, want Python", l.Name)
+	}
+}
+
+func TestLoadLanguagesMissingDir(t *testing.T) {
+	langs, err := LoadLanguages(filepath.Join("gdocs-missing-dir", "languages"))
+	if langs != nil || err != nil {
+		t.Errorf("LoadLanguages() = %v, %v, want no languages", langs, err)
+	}
+}
+
+func TestLoadLanguagesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		def  string
+		want string
+	}{
+		{"unknown field", `{"name": "A", "color": "#000000"}`, "unknown field"},
+		{"unknown theme", `{"name": "A", "themes": {"blue": {}}}`, "unknown theme `blue`"},
+		{"invalid color", `{"name": "A", "themes": {"dark": {"ranges": [{"start": "#", "end": "\n", "color": "red"}]}}}`, "invalid color `red`"},
+		{"range without end", `{"name": "A", "themes": {"dark": {"ranges": [{"start": "#", "color": "#000000"}]}}}`, "range needs start and end symbols"},
+		{"words and pattern", `{"name": "A", "themes": {"light": {"keywords": [{"words": ["a"], "pattern": "b", "color": "#000000"}]}}}`, "not both"},
+		{"invalid group", `{"name": "A", "themes": {"light": {"keywords": [{"pattern": "(a)", "groups": {"x": "#000000"}}]}}}`, "invalid group `x`"},
+		{"invalid heuristic", `{"name": "A", "detect": {"heuristics": ["("]}}`, "missing closing )"},
+		{"no name", `{}`, "language has no name"},
+		{"taken name", `{"name": "Go"}`, "already registered"},
+		{"taken alias", `{"name": "A", "aliases": ["js"]}`, "already registered"},
+	}
+	for _, tt := range tests {
+		dir := writeDefinitions(t, map[string]string{"a.json": tt.def})
+		langs, err := LoadLanguages(dir)
+		os.RemoveAll(dir)
+		for _, l := range langs {
+			unregister(l)
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "a.json") {
+			t.Errorf("%s: LoadLanguages() error = %v, want it to contain the file and %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestLoadLanguagesThemes(t *testing.T) {
+	dir := writeDefinitions(t, map[string]string{"a.json": `{
+		"name": "Test Lang",
+		"themes": {"dark": {
+			"ranges": [{"start": "#", "end": "\n", "color": "#6A9955"}, {"pattern": "\\d+", "color": "B5CEA8"}],
+			"keywords": [{"words": ["a.b"], "color": "#569CD6"}]
+		}}
+	}`})
+	defer os.RemoveAll(dir)
+	langs, err := LoadLanguages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unregister(langs[0])
+
+	// the name is registered with spaces as `_`
+	l, ok := GetLanguage("test_lang")
+	if !ok {
+		t.Fatal("GetLanguage(\"test_lang\") found no language")
+	}
+	dark := l.Themes[darkTheme]
+	if len(dark.Ranges) != 2 || !reflect.DeepEqual(dark.Ranges[0].Color, getColorFromHex("6A9955")) || !dark.Ranges[1].Pattern.MatchString("12") {
+		t.Errorf("dark ranges = %v", dark.Ranges)
+	}
+	// the pattern of a range matches at its start
+	if dark.Ranges[1].Pattern.MatchString("a1") {
+		t.Error("range pattern matches after its start")
+	}
+	// words are quoted, whole words
+	k := dark.Keywords[0].Regex
+	if !k.MatchString("x a.b y") || k.MatchString("axb") || k.MatchString("ba.b") {
+		t.Errorf("keyword regex = %s", k)
+	}
+	// a theme that is not declared has no ranges or keywords
+	if light := l.Themes[lightTheme]; light == nil || len(light.Ranges) != 0 || len(light.Keywords) != 0 {
+		t.Errorf("light theme = %+v, want an empty theme", light)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	l := &Language{Name: "Test Lang", Aliases: []string{"tl"}, Themes: map[string]*Theme{
+		darkTheme:  getDarkTheme(nil, nil),
+		lightTheme: getLightTheme(nil, nil),
+	}}
+	if err := Register(l); err != nil {
+		t.Fatal(err)
+	}
+	defer unregister(l)
+	if got, ok := GetLanguage("TL"); !ok || got != l {
+		t.Errorf("GetLanguage(\"TL\") = %v, %v, want the registered language", got, ok)
+	}
+	if err := Register(l); err == nil {
+		t.Error("Register() of a registered language succeeded, want an error")
+	}
+	if err := Register(&Language{Name: "No Themes"}); err == nil || !strings.Contains(err.Error(), "theme") {
+		t.Errorf("Register() error = %v, want a missing theme", err)
+	}
+	if _, ok := GetLanguage("no_themes"); ok {
+		t.Error("a language that failed to register was registered")
+	}
+}
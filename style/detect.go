@@ -0,0 +1,77 @@
+package style
+
+import (
+	"regexp"
+	"sort"
+)
+
+const (
+	heuristicScore = 10 // score of each heuristic that matches
+	minDetectScore = 10 // minimum score of a detected language
+)
+
+var (
+	// A shebang names its interpreter directly or through `env`
+	// (e.g. `#!/bin/bash` or `#!/usr/bin/env -S bash -e`).
+	shebangRegex = regexp.MustCompile("^#!\\s*\\S*/(?:env\\s+(?:-\\S+\\s+)*)?(\\w+)")
+)
+
+// Detection describes how to detect a language
+// when it is not set by a directive.
+type Detection struct {
+	Interpreters []string         // interpreters named by a shebang
+	Heuristics   []*regexp.Regexp // patterns typical of the language, each adding to the score if found
+	Keywords     []*regexp.Regexp // keywords, each match adding to the score
+}
+
+// Scores code for a detection, where heuristics
+// outweigh the frequency of keywords.
+func (d *Detection) score(code string) (score int) {
+	for _, h := range d.Heuristics {
+		if h.MatchString(code) {
+			score += heuristicScore
+		}
+	}
+	for _, k := range d.Keywords {
+		score += len(k.FindAllStringIndex(code, -1))
+	}
+	return
+}
+
+// DetectLanguage detects the language of code, first by its shebang,
+// then by the registered language with the highest score.
+// If no language is detected, it returns plain text.
+func DetectLanguage(code string) *Language {
+	// languages in a stable order, so ties go to the first name
+	var names []string
+	for name := range languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if res := shebangRegex.FindStringSubmatch(code); res != nil {
+		for _, name := range names {
+			if l := languages[name]; l.Detect != nil {
+				for _, interpreter := range l.Detect.Interpreters {
+					if interpreter == res[1] {
+						return l
+					}
+				}
+			}
+		}
+	}
+
+	best, bestScore := plainLang, minDetectScore-1
+	scored := make(map[*Language]bool)
+	for _, name := range names {
+		l := languages[name]
+		if l.Detect == nil || scored[l] {
+			continue
+		}
+		scored[l] = true
+		if score := l.Detect.score(code); score > bestScore {
+			best, bestScore = l, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,43 @@
+package style
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want *Language
+	}{
+		{"bash shebang", "#!/bin/bash\necho hi\n", bashLang},
+		{"env shebang", "#!/usr/bin/env -S bash -e\nfunc main() {}\n", bashLang},
+		{"go", "package main\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n", goLang},
+		{"java", "public class Main {\n\tpublic static void main(String[] args) {\n\t\tSystem.out.println(1);\n\t}\n}\n", javaLang},
+		{"kotlin", "fun main() {\n\tval x = 1\n\tprintln(x)\n}\n", kotlinLang},
+		{"c", "#include <stdio.h>\nint main(void) { printf(\"hi\\n\"); }\n", cLang},
+		{"rust", "fn main() {\n\tlet mut x = 1;\n\tprintln!(\"{}\", x);\n}\n", rustLang},
+		{"html", "<!DOCTYPE html>\n<html><body><p>hi</p></body></html>\n", htmlLang},
+		{"markdown", "# Title\n\nSome **bold** [link](http://x)\n", markdownLang},
+		{"sql", "SELECT name FROM users WHERE id = 1;\n", sqlLang},
+		{"prose", "hello world\n", plainLang},
+		{"empty", "", plainLang},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.code); got != tt.want {
+			t.Errorf("%s: DetectLanguage() = %s, want %s", tt.name, got.Name, tt.want.Name)
+		}
+	}
+}
+
+func TestDetectionScore(t *testing.T) {
+	d := &Detection{Heuristics: []*regexp.Regexp{regexp.MustCompile("^a")}, Keywords: []*regexp.Regexp{regexp.MustCompile("b")}}
+	// a heuristic outweighs the keywords
+	if got := d.score("abb"); got != heuristicScore+2 {
+		t.Errorf("score() = %d, want %d", got, heuristicScore+2)
+	}
+	if got := d.score("c"); got != 0 {
+		t.Errorf("score() = %d, want 0", got)
+	}
+}
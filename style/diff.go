@@ -0,0 +1,28 @@
+package style
+
+import (
+	"regexp"
+)
+
+var (
+	// heuristics to detect a diff, which has hunk headers or file headers
+	diffHunkHeader  = regexp.MustCompile("(?m)^@@ -\\d+(,\\d+)? \\+\\d+(,\\d+)? @@")
+	diffFileHeaders = regexp.MustCompile("(?m)^--- \\S.*\\n\\+\\+\\+ \\S")
+	diffDetection   = &Detection{
+		Heuristics: []*regexp.Regexp{diffHunkHeader, diffFileHeaders},
+	}
+)
+
+var (
+	// The lines of a diff are colored by their change (like #diff),
+	// and their code is plain text.
+	diffLang = &Language{
+		Name:   "Diff",
+		Diff:   true,
+		Detect: diffDetection,
+		Themes: map[string]*Theme{
+			darkTheme:  getDarkTheme(nil, nil),
+			lightTheme: getLightTheme(nil, nil),
+		},
+	}
+)
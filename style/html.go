@@ -0,0 +1,76 @@
+package style
+
+import (
+	"GDocs-Syntax-Highlighter/runner"
+	"regexp"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// Note that some of the following HTML regexes are taken/inspired from the VSCode language files found here:
+	// https://github.com/microsoft/vscode/tree/master/extensions/html/syntaxes
+	htmlStartTag   = regexp.MustCompile("^<[A-Za-z][\\w:-]*")
+	htmlEndTag     = regexp.MustCompile("^</[A-Za-z][\\w:-]*")
+	htmlDoctype    = regexp.MustCompile("^<![A-Za-z][^>]*>")
+	htmlEntity     = regexp.MustCompile("^&(#\\d+|#[xX][\\da-fA-F]+|\\w+);")
+	htmlAttribute  = regexp.MustCompile("^[^\\s\"'<>/=]+")
+	htmlAfterSpace = regexp.MustCompile("\\s$")
+
+	// heuristics to detect HTML
+	htmlDoctypeHTML = regexp.MustCompile("(?i)<!DOCTYPE\\s+html")
+	htmlElement     = regexp.MustCompile("(?i)<(html|head|body|div|p|span|a|ul|li|h[1-6])\\b[^<>]*>")
+	htmlClose       = regexp.MustCompile("</[A-Za-z][\\w-]*>")
+	htmlDetection   = &Detection{
+		Heuristics: []*regexp.Regexp{htmlDoctypeHTML, htmlElement, htmlClose},
+	}
+
+	// The code of a `<script>` or `<style>` element starts after its start tag.
+	htmlScriptFollows = regexp.MustCompile("(?i)<script\\b[^<>]*>$")
+	htmlStyleFollows  = regexp.MustCompile("(?i)<style\\b[^<>]*>$")
+
+	htmlMainShortcut = &Shortcut{
+		regexp.MustCompile("\\*\\*main\\*\\*"),
+		"<!DOCTYPE html>\n<html>\n<head>\n\t<title>hello world</title>\n</head>\n<body>\n\t<p>hello world</p>\n</body>\n</html>\n",
+	}
+)
+
+// Gets the HTML ranges for particular colors, where the code of `<script>`
+// and `<style>` elements is highlighted with the JavaScript and CSS themes.
+// The code ends at the element's end tag, which receives the tag color.
+func getHTMLRanges(comment, tag, attribute, value *docs.Color, script, style *Theme) []*Range {
+	return []*Range{
+		{StartSymbol: "<!--", EndSymbol: "-->", Color: comment},
+		{EndSymbol: "</script>", Color: tag, Follows: htmlScriptFollows, Embed: script},
+		{EndSymbol: "</style>", Color: tag, Follows: htmlStyleFollows, Embed: style},
+		{Pattern: htmlDoctype, Color: tag},
+		{StartPattern: htmlEndTag, EndSymbol: ">", Color: tag},
+		{StartPattern: htmlStartTag, EndSymbol: ">", Color: tag, Inner: []*Range{
+			{StartSymbol: "\"", EndSymbol: "\"", Color: value},
+			{StartSymbol: "'", EndSymbol: "'", Color: value},
+			{Pattern: htmlAttribute, Color: attribute, Follows: htmlAfterSpace},
+		}},
+		{Pattern: htmlEntity, Color: attribute},
+	}
+}
+
+var (
+	htmlLang = &Language{
+		Name:      "HTML",
+		Format:    runner.FormatHTML,
+		Detect:    htmlDetection,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes, htmlMainShortcut},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getHTMLRanges(DarkThemeDarkGreen, DarkThemeDarkBlue, DarkThemeLightBlue, DarkThemeLightRedOrange,
+					javaScriptLang.Themes[darkTheme], cssLang.Themes[darkTheme]),
+				nil,
+			),
+			lightTheme: getLightTheme(
+				getHTMLRanges(LightThemeDarkGreen, LightThemeMaroon, LightThemeRed, Blue,
+					javaScriptLang.Themes[lightTheme], cssLang.Themes[lightTheme]),
+				nil,
+			),
+		},
+	}
+)
@@ -0,0 +1,150 @@
+package style
+
+import (
+	"GDocs-Syntax-Highlighter/runner"
+	"regexp"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// Note that some of the following Java/Kotlin regexes are taken/inspired from the VSCode language files found here:
+	// https://github.com/microsoft/vscode/tree/master/extensions/java/syntaxes
+	// Type names are capitalized (e.g. `List<T>`), so generics are highlighted as types.
+	java1   = regexp.MustCompile("\\b(break|case|catch|continue|default|do|else|finally|for|if|return|switch|throw|try|while|yield)\\b")
+	java2   = regexp.MustCompile("\\b(abstract|assert|class|enum|extends|false|final|implements|import|instanceof|interface|native|new|non-sealed|null|package|permits|private|protected|public|record|sealed|static|strictfp|super|synchronized|this|throws|transient|true|var|volatile)\\b")
+	java3   = regexp.MustCompile("\\b(boolean|byte|char|double|float|int|long|short|void|[A-Z]|[A-Z][a-z]\\w*)\\b")
+	java4   = regexp.MustCompile("\\b(charAt|equals|format|hashCode|length|print|printf|println|size|substring|toString|valueOf)\\b")
+	java5   = regexp.MustCompile("\\b(0[xX][\\da-fA-F_]+|0[bB][01_]+|\\d[\\d_]*(\\.\\d[\\d_]*)?([eE][+-]?\\d+)?)[lLfFdD]?\\b")
+	kotlin1 = regexp.MustCompile("\\b(break|catch|continue|do|else|finally|for|if|in|is|return|throw|try|when|while)\\b")
+	kotlin2 = regexp.MustCompile("\\b(abstract|annotation|as|by|class|companion|const|data|enum|false|fun|import|infix|init|inline|interface|internal|lateinit|null|object|open|operator|out|override|package|private|protected|public|reified|sealed|super|suspend|tailrec|this|true|typealias|val|var|vararg)\\b")
+	kotlin3 = regexp.MustCompile("\\b([A-Z]|[A-Z][a-z]\\w*)\\b")
+	kotlin4 = regexp.MustCompile("\\b(also|apply|arrayOf|check|emptyList|error|lazy|let|listOf|mapOf|mutableListOf|mutableMapOf|print|println|repeat|require|run|setOf|TODO|with)\\b")
+
+	// An annotation does not follow a word, so `this@Outer` is a label.
+	javaAnnotation        = regexp.MustCompile("^@[A-Za-z_][\\w.:]*")
+	javaAnnotationFollows = regexp.MustCompile("(^|\\W)$")
+
+	// A Kotlin string template (e.g. `$name`), where `${...}` is code.
+	kotlinTemplate      = regexp.MustCompile("^\\$[A-Za-z_]\\w*")
+	kotlinTemplateBlock = &Interpolation{StartSymbol: "${", EndSymbol: "}", Open: "{"}
+
+	// An escape sequence inside a string or character.
+	javaEscape   = regexp.MustCompile("^\\\\([btnfrs\\\\'\"]|u+[0-9a-fA-F]{4}|[0-3][0-7]{2}|[0-7]{1,2})")
+	kotlinEscape = regexp.MustCompile("^\\\\([tbnr\\\\'\"$]|u[0-9a-fA-F]{4})")
+
+	// heuristics to detect Java/Kotlin
+	javaMain      = regexp.MustCompile("\\bpublic\\s+static\\s+void\\s+main\\b")
+	javaPrint     = regexp.MustCompile("\\bSystem\\.(out|err)\\.print")
+	javaMember    = regexp.MustCompile("\\b(public|private|protected)\\s+(static\\s+)?(final\\s+)?[\\w<>\\[\\]]+\\s+\\w+\\s*[;=(]")
+	kotlinFun     = regexp.MustCompile("\\bfun\\s+(<[^>]*>\\s*)?[\\w.]+\\s*\\(")
+	kotlinVal     = regexp.MustCompile("\\b(val|var)\\s+\\w+\\s*(:\\s*[\\w<>?]+\\s*)?=")
+	kotlinPrint   = regexp.MustCompile("\\bprintln\\(")
+	javaDetection = &Detection{
+		Heuristics: []*regexp.Regexp{javaMain, javaPrint, javaMember},
+		Keywords:   []*regexp.Regexp{java1, java2},
+	}
+	kotlinDetection = &Detection{
+		Heuristics: []*regexp.Regexp{kotlinFun, kotlinVal, kotlinPrint},
+		Keywords:   []*regexp.Regexp{kotlin1, kotlin2},
+	}
+
+	javaMainShortcut = &Shortcut{
+		regexp.MustCompile("\\*\\*main\\*\\*"),
+		"public class Main {\n\tpublic static void main(String[] args) {\n\t\tSystem.out.println(\"hello world\");\n\t}\n}\n",
+	}
+	kotlinMainShortcut = &Shortcut{
+		regexp.MustCompile("\\*\\*main\\*\\*"),
+		"fun main() {\n\tprintln(\"hello world\")\n}\n",
+	}
+)
+
+// Gets the Java ranges for particular colors.
+// A text block (`"""`) may contain unescaped quotes.
+func getJavaRanges(comment, str, escape, annotation *docs.Color) []*Range {
+	escapes := []*Range{{Pattern: javaEscape, Color: escape}}
+	return []*Range{
+		{StartSymbol: "//", EndSymbol: "\n", Color: comment},
+		{StartSymbol: "/*", EndSymbol: "*/", Color: comment},
+		{StartSymbol: "\"\"\"", EndSymbol: "\"\"\"", Color: str, Escape: "\\", Inner: escapes},
+		{StartSymbol: "\"", EndSymbol: "\"", Color: str, Escape: "\\", SingleLine: true, Inner: escapes},
+		{StartSymbol: "'", EndSymbol: "'", Color: str, Escape: "\\", SingleLine: true, Inner: escapes},
+		{Pattern: javaAnnotation, Color: annotation, Follows: javaAnnotationFollows},
+	}
+}
+
+// Gets the Kotlin ranges for particular colors.
+// Block comments nest, and a raw string (`"""`) has no escapes.
+func getKotlinRanges(comment, str, escape, annotation, template *docs.Color) []*Range {
+	templates := []*Range{{Pattern: kotlinTemplate, Color: template}}
+	escapes := []*Range{{Pattern: kotlinEscape, Color: escape}}
+	return []*Range{
+		{StartSymbol: "//", EndSymbol: "\n", Color: comment},
+		{StartSymbol: "/*", EndSymbol: "*/", Color: comment, Nested: true},
+		{StartSymbol: "\"\"\"", EndSymbol: "\"\"\"", Color: str, Interpolations: []*Interpolation{kotlinTemplateBlock}, Inner: templates},
+		{StartSymbol: "\"", EndSymbol: "\"", Color: str, Escape: "\\", SingleLine: true, Interpolations: []*Interpolation{kotlinTemplateBlock}, Inner: append(escapes, templates...)},
+		{StartSymbol: "'", EndSymbol: "'", Color: str, Escape: "\\", SingleLine: true, Inner: escapes},
+		{Pattern: javaAnnotation, Color: annotation, Follows: javaAnnotationFollows},
+	}
+}
+
+var (
+	javaLang = &Language{
+		Name:      "Java",
+		Format:    runner.FormatJava,
+		Run:       runner.RunJava,
+		Detect:    javaDetection,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes, javaMainShortcut},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getJavaRanges(DarkThemeDarkGreen, DarkThemeLightRedOrange, DarkThemeStrawYellow, DarkThemeStrawYellow),
+				[]Keyword{
+					{Regex: java1, Color: DarkThemePink},
+					{Regex: java2, Color: DarkThemeDarkBlue},
+					{Regex: java3, Color: DarkThemeGreenCyan},
+					{Regex: java4, Color: DarkThemeYellow},
+					{Regex: java5, Color: DarkThemePaleGreen},
+				},
+			),
+			lightTheme: getLightTheme(
+				getJavaRanges(LightThemeDarkGreen, LightThemeDarkRed, LightThemeBrightRed, LightThemeStrawYellow),
+				[]Keyword{
+					{Regex: java1, Color: LightThemePink},
+					{Regex: java2, Color: Blue},
+					{Regex: java3, Color: LightThemeGreenCyan},
+					{Regex: java4, Color: LightThemeStrawYellow},
+					{Regex: java5, Color: LightThemePaleGreen},
+				},
+			),
+		},
+	}
+	kotlinLang = &Language{
+		Name:      "Kotlin",
+		Format:    runner.FormatKotlin,
+		Run:       runner.RunKotlin,
+		Detect:    kotlinDetection,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes, kotlinMainShortcut},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getKotlinRanges(DarkThemeDarkGreen, DarkThemeLightRedOrange, DarkThemeStrawYellow, DarkThemeStrawYellow, DarkThemeLightBlue),
+				[]Keyword{
+					{Regex: kotlin1, Color: DarkThemePink},
+					{Regex: kotlin2, Color: DarkThemeDarkBlue},
+					{Regex: kotlin3, Color: DarkThemeGreenCyan},
+					{Regex: kotlin4, Color: DarkThemeYellow},
+					{Regex: java5, Color: DarkThemePaleGreen},
+				},
+			),
+			lightTheme: getLightTheme(
+				getKotlinRanges(LightThemeDarkGreen, LightThemeDarkRed, LightThemeBrightRed, LightThemeStrawYellow, LightThemeNavy),
+				[]Keyword{
+					{Regex: kotlin1, Color: LightThemePink},
+					{Regex: kotlin2, Color: Blue},
+					{Regex: kotlin3, Color: LightThemeGreenCyan},
+					{Regex: kotlin4, Color: LightThemeStrawYellow},
+					{Regex: java5, Color: LightThemePaleGreen},
+				},
+			),
+		},
+	}
+)
@@ -0,0 +1,146 @@
+package style
+
+import (
+	"GDocs-Syntax-Highlighter/runner"
+	"regexp"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// Note that some of the following JavaScript/TypeScript regexes are taken/inspired from the VSCode language files found here:
+	// https://github.com/microsoft/vscode/tree/master/extensions/javascript/syntaxes
+	// https://github.com/microsoft/vscode/tree/master/extensions/typescript-basics/syntaxes
+	js1 = regexp.MustCompile("\\b(break|case|catch|continue|debugger|default|do|else|export|finally|for|from|if|import|return|switch|throw|try|while|with|yield|await)\\b")
+	js2 = regexp.MustCompile("\\b(async|class|const|delete|extends|false|function|get|in|instanceof|let|new|null|of|set|static|super|this|true|typeof|undefined|var|void)\\b")
+	js3 = regexp.MustCompile("\\b(Array|BigInt|Boolean|Date|Error|Function|JSON|Map|Math|Number|Object|Promise|Proxy|Reflect|RegExp|Set|String|Symbol|WeakMap|WeakSet|console)\\b")
+	js4 = regexp.MustCompile("\\b(clearInterval|clearTimeout|fetch|isFinite|isNaN|parseFloat|parseInt|require|setInterval|setTimeout)\\b")
+	js5 = regexp.MustCompile("\\b(0[xX][\\da-fA-F_]+|0[bB][01_]+|0[oO][0-7_]+|\\d[\\d_]*(\\.\\d+)?([eE][+-]?\\d+)?)n?\\b")
+	ts1 = regexp.MustCompile("\\b(abstract|as|asserts|declare|enum|implements|infer|interface|is|keyof|module|namespace|private|protected|public|readonly|satisfies|type)\\b")
+	ts2 = regexp.MustCompile("\\b(any|bigint|boolean|never|number|object|string|symbol|unknown)\\b")
+
+	// A regex literal can only follow an operator, an opening bracket,
+	// or a keyword, otherwise `/` is division.
+	jsRegexFollows = regexp.MustCompile("(^|[(,=:\\[!&|?{};+\\-*%~^]|\\b(return|typeof|case|do|else|in|of|new|delete|void|throw|yield|await))\\s*$")
+	jsRegexLiteral = regexp.MustCompile("^/([^/\\\\\\[\\n]|\\\\.|\\[([^\\]\\\\\\n]|\\\\.)*\\])+/[dgimsuvy]*")
+
+	// A JSX tag (e.g. `<div` or `</div`) can only follow an opening bracket,
+	// an operator, or `return`, otherwise `<` is a comparison (or a generic in TypeScript).
+	jsxTagFollows = regexp.MustCompile("(^|[(,=:?\\[{>]|&&|\\|\\||\\breturn)\\s*$")
+	jsxTag        = regexp.MustCompile("^</?[A-Za-z][\\w.:-]*")
+
+	// JSX text follows the `>` of an opening tag on the same line,
+	// and the tag name after it follows the `<` ending the text.
+	jsxTextFollows    = regexp.MustCompile("<[A-Za-z][\\w.:-]*(\\s+[\\w.:-]+(=(\"[^\"]*\"|'[^']*'|\\{[^{}]*\\}))?)*\\s*$")
+	jsxTextTagFollows = regexp.MustCompile("(^\\s*|>[^<>&|;]*)<$")
+	jsxTextTag        = regexp.MustCompile("^/?[A-Za-z][\\w.:-]*")
+	jsxExpression     = &Interpolation{"{", "}", "{"}
+	jsTemplateLiteral = &Interpolation{"${", "}", "{"}
+
+	// An escape sequence inside a string.
+	jsEscape = regexp.MustCompile("^\\\\(x[0-9a-fA-F]{2}|u[0-9a-fA-F]{4}|u\\{[0-9a-fA-F]{1,6}\\}|[0-3][0-7]{2}|[0-7]{1,2}|[bfnrtv\\\\'\"`$])")
+
+	// heuristics to detect JavaScript/TypeScript, where TypeScript also has types
+	jsDeclaration = regexp.MustCompile("\\b(const|let)\\s+\\w+\\s*=")
+	jsArrow       = regexp.MustCompile("=>")
+	jsConsole     = regexp.MustCompile("\\b(console\\.\\w+|require|document\\.\\w+)\\(")
+	jsFunction    = regexp.MustCompile("\\bfunction\\s*\\w*\\s*\\(")
+	tsAnnotation  = regexp.MustCompile(":\\s*(string|number|boolean|any|void)\\b")
+	tsInterface   = regexp.MustCompile("(?m)^\\s*(export\\s+)?(interface\\s+\\w+|type\\s+\\w+\\s*=)")
+	jsHeuristics  = []*regexp.Regexp{jsDeclaration, jsArrow, jsConsole, jsFunction}
+	jsDetection   = &Detection{
+		Heuristics: jsHeuristics,
+		Keywords:   []*regexp.Regexp{js1, js2},
+	}
+	tsDetection = &Detection{
+		Heuristics: append([]*regexp.Regexp{tsAnnotation, tsInterface}, jsHeuristics...),
+		Keywords:   []*regexp.Regexp{js1, js2},
+	}
+)
+
+// Gets the JavaScript/TypeScript ranges for particular colors.
+// JSX ranges are optional since they are ambiguous with TypeScript generics.
+func getJavaScriptRanges(comment, str, escape, regex, tag, text *docs.Color, jsx bool) []*Range {
+	escapes := []*Range{{Pattern: jsEscape, Color: escape}}
+	ranges := []*Range{
+		{StartSymbol: "//", EndSymbol: "\n", Color: comment},
+		{StartSymbol: "/*", EndSymbol: "*/", Color: comment},
+		{StartSymbol: "\"", EndSymbol: "\"", Color: str, Escape: "\\", SingleLine: true, Inner: escapes},
+		{StartSymbol: "'", EndSymbol: "'", Color: str, Escape: "\\", SingleLine: true, Inner: escapes},
+		{StartSymbol: "`", EndSymbol: "`", Color: str, Escape: "\\", Interpolations: []*Interpolation{jsTemplateLiteral}, Inner: escapes},
+	}
+	if jsx {
+		// before regex literals, since `</div>` could otherwise be a regex
+		ranges = append(ranges,
+			&Range{Pattern: jsxTag, Follows: jsxTagFollows, Color: tag},
+			&Range{StartSymbol: ">", EndSymbol: "<", Follows: jsxTextFollows, Color: text, Interpolations: []*Interpolation{jsxExpression}},
+			&Range{Pattern: jsxTextTag, Follows: jsxTextTagFollows, Color: tag},
+		)
+	}
+	return append(ranges, &Range{Pattern: jsRegexLiteral, Follows: jsRegexFollows, Color: regex})
+}
+
+var (
+	javaScriptLang = &Language{
+		Name:      "JavaScript",
+		Format:    runner.FormatJavaScript,
+		Run:       runner.RunJavaScript,
+		Detect:    jsDetection,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getJavaScriptRanges(DarkThemeDarkGreen, DarkThemeLightRedOrange, DarkThemeStrawYellow, DarkThemeLightRed, DarkThemeDarkBlue, DarkThemeForeground, true),
+				[]Keyword{
+					{Regex: js1, Color: DarkThemePink},
+					{Regex: js2, Color: DarkThemeDarkBlue},
+					{Regex: js3, Color: DarkThemeGreenCyan},
+					{Regex: js4, Color: DarkThemeYellow},
+					{Regex: js5, Color: DarkThemePaleGreen},
+				},
+			),
+			lightTheme: getLightTheme(
+				getJavaScriptRanges(LightThemeDarkGreen, LightThemeDarkRed, LightThemeBrightRed, LightThemeDarkMaroon, LightThemeMaroon, Black, true),
+				[]Keyword{
+					{Regex: js1, Color: LightThemePink},
+					{Regex: js2, Color: Blue},
+					{Regex: js3, Color: LightThemeGreenCyan},
+					{Regex: js4, Color: LightThemeStrawYellow},
+					{Regex: js5, Color: LightThemePaleGreen},
+				},
+			),
+		},
+	}
+	typeScriptLang = &Language{
+		Name:      "TypeScript",
+		Format:    runner.FormatTypeScript,
+		Run:       runner.RunTypeScript,
+		Detect:    tsDetection,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getJavaScriptRanges(DarkThemeDarkGreen, DarkThemeLightRedOrange, DarkThemeStrawYellow, DarkThemeLightRed, DarkThemeDarkBlue, DarkThemeForeground, false),
+				[]Keyword{
+					{Regex: js1, Color: DarkThemePink},
+					{Regex: js2, Color: DarkThemeDarkBlue},
+					{Regex: ts1, Color: DarkThemeDarkBlue},
+					{Regex: js3, Color: DarkThemeGreenCyan},
+					{Regex: ts2, Color: DarkThemeGreenCyan},
+					{Regex: js4, Color: DarkThemeYellow},
+					{Regex: js5, Color: DarkThemePaleGreen},
+				},
+			),
+			lightTheme: getLightTheme(
+				getJavaScriptRanges(LightThemeDarkGreen, LightThemeDarkRed, LightThemeBrightRed, LightThemeDarkMaroon, LightThemeMaroon, Black, false),
+				[]Keyword{
+					{Regex: js1, Color: LightThemePink},
+					{Regex: js2, Color: Blue},
+					{Regex: ts1, Color: Blue},
+					{Regex: js3, Color: LightThemeGreenCyan},
+					{Regex: ts2, Color: LightThemeGreenCyan},
+					{Regex: js4, Color: LightThemeStrawYellow},
+					{Regex: js5, Color: LightThemePaleGreen},
+				},
+			),
+		},
+	}
+)
@@ -0,0 +1,63 @@
+package style
+
+import (
+	"GDocs-Syntax-Highlighter/runner"
+	"regexp"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// Note that some of the following JSON regexes are taken/inspired from the VSCode language files found here:
+	// https://github.com/microsoft/vscode/tree/master/extensions/json/syntaxes
+	json1 = regexp.MustCompile("\\b(true|false|null)\\b")
+	json2 = regexp.MustCompile("-?\\b\\d+(\\.\\d+)?([eE][+-]?\\d+)?\\b")
+
+	// heuristics to detect JSON, which is a single object or array of quoted keys
+	jsonDocument  = regexp.MustCompile("^\\s*[{\\[][\\s\\S]*[}\\]]\\s*$")
+	jsonKey       = regexp.MustCompile("\"[^\"\\n]*\"\\s*:\\s*[\"\\d\\[{tfn-]")
+	jsonDetection = &Detection{
+		Heuristics: []*regexp.Regexp{jsonDocument, jsonKey},
+	}
+
+	// A key is a string followed by a colon.
+	jsonKeyPrecedes = regexp.MustCompile("^\\s*:")
+
+	// An escape sequence inside a string.
+	jsonEscape = regexp.MustCompile("^\\\\([\"\\\\/bfnrt]|u[0-9a-fA-F]{4})")
+)
+
+// Gets the JSON ranges for particular colors.
+func getJSONRanges(key, str, escape *docs.Color) []*Range {
+	escapes := []*Range{{Pattern: jsonEscape, Color: escape}}
+	return []*Range{
+		{StartSymbol: "\"", EndSymbol: "\"", Color: key, Escape: "\\", SingleLine: true, Precedes: jsonKeyPrecedes, Inner: escapes},
+		{StartSymbol: "\"", EndSymbol: "\"", Color: str, Escape: "\\", SingleLine: true, Inner: escapes},
+	}
+}
+
+var (
+	jsonLang = &Language{
+		Name:      "JSON",
+		Format:    runner.FormatJSON,
+		Run:       runner.RunJSON,
+		Detect:    jsonDetection,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getJSONRanges(DarkThemeLightBlue, DarkThemeLightRedOrange, DarkThemeStrawYellow),
+				[]Keyword{
+					{Regex: json1, Color: DarkThemeDarkBlue},
+					{Regex: json2, Color: DarkThemePaleGreen},
+				},
+			),
+			lightTheme: getLightTheme(
+				getJSONRanges(LightThemeCobalt, LightThemeDarkRed, LightThemeBrightRed),
+				[]Keyword{
+					{Regex: json1, Color: Blue},
+					{Regex: json2, Color: LightThemePaleGreen},
+				},
+			),
+		},
+	}
+)
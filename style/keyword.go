@@ -8,20 +8,52 @@ import (
 
 // Keyword represents a language keyword
 // and the color it is highlighted with (for a theme).
+// Since Go's regexes do not support lookarounds, the context of
+// a keyword can be matched around a capture group that is highlighted instead.
 type Keyword struct {
-	Regex *regexp.Regexp
-	Color *docs.Color
+	Regex  *regexp.Regexp
+	Color  *docs.Color
+	Group  int                 // capture group that is highlighted with the color, where 0 is the whole match (e.g. 1 for the name in `func (\w+)\(`)
+	Groups map[int]*docs.Color // if set, capture groups that are highlighted with their own colors, after the color (which can be nil)
 }
 
 var (
 	// Note that some of the following Go regexes are taken/inspired from the VSCode language files found here:
 	// https://github.com/microsoft/vscode/blob/master/extensions/go/syntaxes/go.tmLanguage.json
-	//
-	// TODO: update regexes to use capturing groups to replace positive lookaheads (since Go doesn't support it)
-	// store some metadata about which regex group to use to highlight?
 	go1 = regexp.MustCompile("\\b(break|case|continue|default|defer|else|fallthrough|for|go|goto|if|range|return|select|switch)\\b")
 	go2 = regexp.MustCompile("\\b(chan|const|func|interface|map|struct|true|false|nil|iota|package|type|import|var)\\b")
 	go3 = regexp.MustCompile("\\b(bool|byte|error|(complex(64|128)|float(32|64)|u?int(8|16|32|64)?)|rune|string|uintptr)\\b")
 	go4 = regexp.MustCompile("\\b(append|cap|close|complex|copy|delete|imag|len|make|new|panic|print|println|real|recover)\\b")
 	go5 = regexp.MustCompile("\\b\\d+\\b")
+	go6 = regexp.MustCompile("\\bfunc\\s*(?:\\([^)]*\\)\\s*)?(\\w+)\\s*[\\[(]") // function name
+	go7 = regexp.MustCompile("\\btype\\s+(\\w+)")                               // type name
+
+	// Escape sequences and fmt verbs inside strings are highlighted,
+	// so that invalid ones stand out (e.g. `\q` or `%z`).
+	goEscape = regexp.MustCompile("^\\\\([abfnrtv\\\\'\"]|x[0-9a-fA-F]{2}|u[0-9a-fA-F]{4}|U[0-9a-fA-F]{8}|[0-7]{3})")
+	goVerb   = regexp.MustCompile("^%([-+# 0]*(\\[\\d+\\])?(\\d+|\\*)?(\\.(\\d+|\\*)?)?(\\[\\d+\\])?[vTtbcdoOqxXUeEfFgGspw]|%)")
+
+	// heuristics to detect Go
+	goPackage   = regexp.MustCompile("(?m)^package\\s+\\w+")
+	goFunc      = regexp.MustCompile("\\bfunc\\s*(\\([^)]*\\)\\s*)?\\w+\\(")
+	goShortVar  = regexp.MustCompile("\\w\\s*:=")
+	goFmt       = regexp.MustCompile("\\bfmt\\.\\w+\\(")
+	goDetection = &Detection{
+		Heuristics: []*regexp.Regexp{goPackage, goFunc, goShortVar, goFmt},
+		Keywords:   []*regexp.Regexp{go1, go2},
+	}
 )
+
+// Gets the Go ranges for particular colors, where strings
+// highlight their escape sequences and fmt verbs.
+func getGoRanges(comment, str, escape, verb *docs.Color) []*Range {
+	escapes := &Range{Pattern: goEscape, Color: escape}
+	verbs := &Range{Pattern: goVerb, Color: verb}
+	return []*Range{
+		{StartSymbol: "//", EndSymbol: "\n", Color: comment},
+		{StartSymbol: "/*", EndSymbol: "*/", Color: comment},
+		{StartSymbol: "\"", EndSymbol: "\"", Color: str, Escape: "\\", SingleLine: true, Inner: []*Range{escapes, verbs}},
+		{StartSymbol: "'", EndSymbol: "'", Color: str, Escape: "\\", SingleLine: true, Inner: []*Range{escapes}},
+		{StartSymbol: "`", EndSymbol: "`", Color: str, Inner: []*Range{verbs}},
+	}
+}
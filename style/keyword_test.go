@@ -0,0 +1,77 @@
+package style
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestEscapeRegexes(t *testing.T) {
+	tests := []struct {
+		name  string
+		regex *regexp.Regexp
+		text  string
+		want  string // the matched escape, empty if invalid
+	}{
+		{"go newline", goEscape, "\\n", "\\n"},
+		{"go hex", goEscape, "\\x41b", "\\x41"},
+		{"go unicode", goEscape, "\\u00e9", "\\u00e9"},
+		{"go long unicode", goEscape, "\\U0001F600", "\\U0001F600"},
+		{"go octal", goEscape, "\\1018", "\\101"},
+		{"go invalid", goEscape, "\\q", ""},
+		{"go short hex", goEscape, "\\x4", ""},
+		{"go short octal", goEscape, "\\10", ""},
+		{"c question mark", cEscape, "\\?", "\\?"},
+		{"c short octal", cEscape, "\\0", "\\0"},
+		{"c long hex", cEscape, "\\x1F2g", "\\x1F2"},
+		{"c invalid", cEscape, "\\q", ""},
+		{"java space", javaEscape, "\\s", "\\s"},
+		{"java unicode", javaEscape, "\\uu0041", "\\uu0041"},
+		{"java octal", javaEscape, "\\377", "\\377"},
+		{"java large octal", javaEscape, "\\477", "\\47"},
+		{"java invalid", javaEscape, "\\x41", ""},
+		{"kotlin dollar", kotlinEscape, "\\$", "\\$"},
+		{"kotlin invalid", kotlinEscape, "\\0", ""},
+		{"javascript code point", jsEscape, "\\u{1F600}", "\\u{1F600}"},
+		{"javascript long code point", jsEscape, "\\u{1234567}", ""},
+		{"javascript large octal", jsEscape, "\\477", "\\47"},
+		{"javascript invalid", jsEscape, "\\q", ""},
+		{"json slash", jsonEscape, "\\/", "\\/"},
+		{"json invalid", jsonEscape, "\\x41", ""},
+		{"rust null", rustEscape, "\\0", "\\0"},
+		{"rust ascii", rustEscape, "\\x7F", "\\x7F"},
+		{"rust non-ascii", rustEscape, "\\x80", ""},
+		{"rust invalid", rustEscape, "\\q", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.regex.FindString(tt.text); got != tt.want {
+			t.Errorf("%s: match of %q = %q, want %q", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestGoVerbRegex(t *testing.T) {
+	tests := []struct {
+		text string
+		want string // the matched verb, empty if invalid
+	}{
+		{"%v", "%v"},
+		{"%-8.2f", "%-8.2f"},
+		{"%+q", "%+q"},
+		{"%#x", "%#x"},
+		{"%*d", "%*d"},
+		{"%.*s", "%.*s"},
+		{"%[1]d", "%[1]d"},
+		{"%6.2[2]f", "%6.2[2]f"},
+		{"%w", "%w"},
+		{"%%", "%%"},
+		{"%z", ""},
+		{"%-8.2", ""},
+		{"% ", ""},
+		{"%", ""},
+	}
+	for _, tt := range tests {
+		if got := goVerb.FindString(tt.text); got != tt.want {
+			t.Errorf("match of %q = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -2,23 +2,32 @@ package style
 
 import (
 	"GDocs-Syntax-Highlighter/runner"
+	"fmt"
 	"strings"
 )
 
 // FormatFunc describes a function that takes in a program
-// as text and returns the formatted program as text, as well as
-// an error if the code could not be formatted (most likely invalid code).
-type FormatFunc func(string) (string, error)
+// as text and directive options, and returns the formatted program as text,
+// as well as an error if the code could not be formatted (most likely invalid code).
+type FormatFunc func(string, runner.Options) (string, error)
 
 // RunFunc describes a function that takes in a program
-// as text, runs it, and returns an output.
-type RunFunc func(string) (*runner.RunResult, error)
+// as text and directive options, runs it, and returns an output.
+type RunFunc func(string, runner.Options) (*runner.RunResult, error)
+
+// LintFunc describes a function that takes in a program
+// as text and directive options, and returns the problems found in it.
+type LintFunc func(string, runner.Options) ([]runner.Diagnostic, error)
 
 // Language represents a programming language.
 type Language struct {
 	Name      string
+	Aliases   []string // other names it can be set with by #lang, for registered languages
 	Format    FormatFunc
 	Run       RunFunc
+	Lint      LintFunc
+	Detect    *Detection
+	Diff      bool // if set, the code is a diff, whose lines are colored by their change
 	Shortcuts []*Shortcut
 	Themes    map[string]*Theme
 }
@@ -28,64 +37,74 @@ var (
 		Name:      "Go",
 		Format:    runner.FormatGo,
 		Run:       runner.RunGo,
+		Detect:    goDetection,
 		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes, goMainShortcut},
 		Themes: map[string]*Theme{
-			darkTheme: {
-				DocBackground:    DarkThemeBackground,
-				CodeForeground:   DarkThemeForeground,
-				CodeBackground:   DarkThemeBackground,
-				CodeHighlight:    Transparent,
-				ConfigForeground: White,
-				ConfigBackground: Black,
-				ConfigHighlight:  Transparent,
-				ConfigFont:       courierNew,
-				ConfigFontSize:   11,
-				ConfigItalics:    true,
-				Ranges: []*Range{
-					{"//", "\n", DarkThemeDarkGreen},
-					{"/*", "*/", DarkThemeDarkGreen},
-					{"\"", "\"", DarkThemeLightRedOrange},
-					{"'", "'", DarkThemeLightRedOrange},
-					{"`", "`", DarkThemeLightRedOrange},
-				},
-				Keywords: []Keyword{
-					{go1, DarkThemePink},
-					{go2, DarkThemeDarkBlue},
-					{go3, DarkThemeGreenCyan},
-					{go4, DarkThemeYellow},
-					{go5, DarkThemePaleGreen},
+			darkTheme: getDarkTheme(
+				getGoRanges(DarkThemeDarkGreen, DarkThemeLightRedOrange, DarkThemeStrawYellow, DarkThemeLightBlue),
+				[]Keyword{
+					{Regex: go1, Color: DarkThemePink},
+					{Regex: go2, Color: DarkThemeDarkBlue},
+					{Regex: go3, Color: DarkThemeGreenCyan},
+					{Regex: go4, Color: DarkThemeYellow},
+					{Regex: go5, Color: DarkThemePaleGreen},
+					{Regex: go6, Color: DarkThemeYellow, Group: 1},
+					{Regex: go7, Color: DarkThemeGreenCyan, Group: 1},
 				},
-			},
-			lightTheme: {
-				DocBackground:    White,
-				CodeForeground:   Black,
-				CodeBackground:   White,
-				CodeHighlight:    Transparent,
-				ConfigForeground: Black,
-				ConfigBackground: LightGray,
-				ConfigHighlight:  Transparent,
-				ConfigFont:       courierNew,
-				ConfigFontSize:   11,
-				ConfigItalics:    true,
-				Ranges: []*Range{
-					{"//", "\n", LightThemeDarkGreen},
-					{"/*", "*/", LightThemeDarkGreen},
-					{"\"", "\"", LightThemeDarkRed},
-					{"'", "'", LightThemeDarkRed},
-					{"`", "`", LightThemeDarkRed},
+			),
+			lightTheme: getLightTheme(
+				getGoRanges(LightThemeDarkGreen, LightThemeDarkRed, LightThemeBrightRed, LightThemeNavy),
+				[]Keyword{
+					{Regex: go1, Color: LightThemePink},
+					{Regex: go2, Color: Blue},
+					{Regex: go3, Color: LightThemeGreenCyan},
+					{Regex: go4, Color: LightThemeStrawYellow},
+					{Regex: go5, Color: LightThemePaleGreen},
+					{Regex: go6, Color: LightThemeStrawYellow, Group: 1},
+					{Regex: go7, Color: LightThemeGreenCyan, Group: 1},
 				},
-				Keywords: []Keyword{
-					{go1, LightThemePink},
-					{go2, Blue},
-					{go3, LightThemeGreenCyan},
-					{go4, LightThemeStrawYellow},
-					{go5, LightThemePaleGreen},
-				},
-			},
+			),
+		},
+	}
+	plainLang = &Language{
+		Name: "Plain Text",
+		Themes: map[string]*Theme{
+			darkTheme:  getDarkTheme(nil, nil),
+			lightTheme: getLightTheme(nil, nil),
 		},
 	}
 	languages = map[string]*Language{
-		"go": goLang,
+		"text":       plainLang,
+		"plain":      plainLang,
+		"go":         goLang,
+		"bash":       bashLang,
+		"sh":         bashLang,
+		"shell":      bashLang,
+		"c":          cLang,
+		"cpp":        cppLang,
+		"rust":       rustLang,
+		"rs":         rustLang,
+		"sql":        sqlLang,
+		"sqlite":     sqlLang,
+		"postgresql": postgresLang,
+		"postgres":   postgresLang,
+		"mysql":      mySQLLang,
+		"html":       htmlLang,
+		"css":        cssLang,
+		"markdown":   markdownLang,
+		"md":         markdownLang,
+		"java":       javaLang,
+		"kotlin":     kotlinLang,
+		"kt":         kotlinLang,
+		"json":       jsonLang,
+		"yaml":       yamlLang,
+		"yml":        yamlLang,
+		"javascript": javaScriptLang,
+		"js":         javaScriptLang,
+		"typescript": typeScriptLang,
+		"ts":         typeScriptLang,
+		"diff":       diffLang,
+		"patch":      diffLang,
 	}
 )
 
@@ -96,8 +115,36 @@ func GetLanguage(lang string) (*Language, bool) {
 	return l, ok
 }
 
-// GetDefaultLanguage gets the default Language
-// if the directive is not set.
-func GetDefaultLanguage() *Language {
-	return goLang
+// Register adds a language, so it can be set by #lang with its name
+// (case insensitive, spaces as `_`) or its aliases, and can be detected.
+// It returns an error if a name is taken or if the language misses a theme.
+// Languages must be registered before documents are processed.
+func Register(l *Language) error {
+	if strings.TrimSpace(l.Name) == "" {
+		return fmt.Errorf("language has no name")
+	}
+	for theme := range themes {
+		if l.Themes[theme] == nil {
+			return fmt.Errorf("language `%s` has no %s theme", l.Name, theme)
+		}
+	}
+
+	keys := []string{getLanguageKey(l.Name)}
+	for _, alias := range l.Aliases {
+		keys = append(keys, getLanguageKey(alias))
+	}
+	for _, key := range keys {
+		if _, ok := languages[key]; ok || key == "" {
+			return fmt.Errorf("language name `%s` of `%s` is already registered or empty", key, l.Name)
+		}
+	}
+	for _, key := range keys {
+		languages[key] = l
+	}
+	return nil
+}
+
+// Gets the key of a language name in the registered languages.
+func getLanguageKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), "_"))
 }
@@ -0,0 +1,87 @@
+package style
+
+import (
+	"GDocs-Syntax-Highlighter/runner"
+	"regexp"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// Note that some of the following Markdown regexes are taken/inspired from the VSCode language files found here:
+	// https://github.com/microsoft/vscode/tree/master/extensions/markdown-basics/syntaxes
+	markdown1 = regexp.MustCompile("(?m)^[ \\t]*([-*+]|\\d+[.)])[ \\t]|^ {0,3}>")
+	markdown2 = regexp.MustCompile("(?m)^ {0,3}(-{3,}|\\*{3,}|_{3,})[ \\t]*$")
+
+	// heuristics to detect Markdown
+	markdownHeadingLine = regexp.MustCompile("(?m)^#{1,6}\\s+\\S")
+	markdownFence       = regexp.MustCompile("(?m)^(```|~~~)")
+	markdownLink        = regexp.MustCompile("\\[[^\\]\\n]+\\]\\([^)\\n]+\\)")
+	markdownStrong      = regexp.MustCompile("\\*\\*[^*\\n]+\\*\\*")
+	markdownDetection   = &Detection{
+		Heuristics: []*regexp.Regexp{markdownHeadingLine, markdownFence, markdownLink, markdownStrong},
+		Keywords:   []*regexp.Regexp{markdown1},
+	}
+
+	// A fenced code block is highlighted with the language after its fence (e.g. ```go).
+	markdownBacktickFence = regexp.MustCompile("^```[ \\t]*([\\w-]*)[^\\n]*")
+	markdownTildeFence    = regexp.MustCompile("^~~~[ \\t]*([\\w-]*)[^\\n]*")
+	markdownInlineCode    = regexp.MustCompile("^(`+)")
+
+	// A heading or a fence starts its line (after up to three spaces).
+	markdownLineFollows = regexp.MustCompile("^ {0,3}$")
+	markdownHeading     = regexp.MustCompile("^#{1,6}([ \\t][^\\n]*)?")
+
+	// A link's text is followed by its destination.
+	markdownLinkText     = regexp.MustCompile("^!?\\[[^\\]\\n]*\\]")
+	markdownLinkPrecedes = regexp.MustCompile("^\\(")
+	markdownLinkFollows  = regexp.MustCompile("\\]$")
+	markdownLinkURL      = regexp.MustCompile("^\\([^)\\n]*\\)")
+	markdownAutolink     = regexp.MustCompile("^<(https?|mailto):[^>\\s]+>")
+
+	// Underscore emphasis does not start inside a word (e.g. `snake_case`).
+	markdownBold            = regexp.MustCompile("^(\\*\\*[^*\\s][^*\\n]*\\*\\*|__[^_\\s][^_\\n]*__)")
+	markdownItalic          = regexp.MustCompile("^(\\*[^*\\s][^*\\n]*\\*|_[^_\\s][^_\\n]*_)")
+	markdownEmphasisFollows = regexp.MustCompile("(^|[^\\w*])$")
+)
+
+// Gets the Markdown ranges for particular colors and the theme name
+// of the languages of code blocks.
+func getMarkdownRanges(heading, code, emphasis, linkText, linkURL *docs.Color, theme string) []*Range {
+	return []*Range{
+		{StartPattern: markdownBacktickFence, EndSymbol: "\n```", Color: code, Follows: markdownLineFollows, EmbedTheme: theme},
+		{StartPattern: markdownTildeFence, EndSymbol: "\n~~~", Color: code, Follows: markdownLineFollows, EmbedTheme: theme},
+		{StartPattern: markdownInlineCode, EndSymbol: "$1", Color: code},
+		{Pattern: markdownHeading, Color: heading, Follows: markdownLineFollows},
+		{Pattern: markdownLinkText, Color: linkText, Precedes: markdownLinkPrecedes},
+		{Pattern: markdownLinkURL, Color: linkURL, Follows: markdownLinkFollows},
+		{Pattern: markdownAutolink, Color: linkURL},
+		{Pattern: markdownBold, Color: emphasis, Follows: markdownEmphasisFollows},
+		{Pattern: markdownItalic, Color: emphasis, Follows: markdownEmphasisFollows},
+	}
+}
+
+var (
+	markdownLang = &Language{
+		Name:      "Markdown",
+		Format:    runner.FormatMarkdown,
+		Detect:    markdownDetection,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getMarkdownRanges(DarkThemeDarkBlue, DarkThemeLightRedOrange, DarkThemeDarkBlue, DarkThemeLightBlue, DarkThemeLightRedOrange, darkTheme),
+				[]Keyword{
+					{Regex: markdown1, Color: DarkThemeCornflowerBlue},
+					{Regex: markdown2, Color: DarkThemeDarkGreen},
+				},
+			),
+			lightTheme: getLightTheme(
+				getMarkdownRanges(LightThemeMaroon, LightThemeMaroon, LightThemeNavy, LightThemeCobalt, LightThemeDarkRed, lightTheme),
+				[]Keyword{
+					{Regex: markdown1, Color: LightThemeCobalt},
+					{Regex: markdown2, Color: LightThemeDarkGreen},
+				},
+			),
+		},
+	}
+)
@@ -0,0 +1,86 @@
+package style
+
+import (
+	"GDocs-Syntax-Highlighter/runner"
+	"regexp"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// Note that some of the following Rust regexes are taken/inspired from the VSCode language files found here:
+	// https://github.com/microsoft/vscode/tree/master/extensions/rust/syntaxes
+	rust1 = regexp.MustCompile("\\b(await|break|continue|else|for|if|in|loop|match|return|while|yield)\\b")
+	rust2 = regexp.MustCompile("\\b(as|async|const|crate|dyn|enum|extern|false|fn|impl|let|mod|move|mut|pub|ref|self|Self|static|struct|super|trait|true|type|union|unsafe|use|where)\\b")
+	rust3 = regexp.MustCompile("\\b(Arc|bool|Box|char|Err|f32|f64|HashMap|HashSet|None|Ok|Option|Rc|Result|Some|str|String|Vec|[iu](8|16|32|64|128|size))\\b")
+	rust4 = regexp.MustCompile("\\b[A-Za-z_]\\w*![(\\[{]")
+	rust5 = regexp.MustCompile("\\b(0x[\\da-fA-F_]+|0o[0-7_]+|0b[01_]+|\\d[\\d_]*(\\.\\d[\\d_]*)?([eE][+-]?\\d+)?)([iu](8|16|32|64|128|size)|f32|f64)?\\b")
+
+	// A char literal is a single (possibly escaped) char between quotes,
+	// otherwise the quote starts a lifetime (e.g. `'a`).
+	rustChar      = regexp.MustCompile("^b?'(\\\\[^'\\n]+|[^'\\\\\\n])'")
+	rustLifetime  = regexp.MustCompile("^'[A-Za-z_]\\w*")
+	rustRawString = regexp.MustCompile("^b?r(#*)\"")
+	rustAttribute = regexp.MustCompile("^#!?\\[")
+	rustEscape    = regexp.MustCompile("^\\\\([nrt\\\\0'\"]|x[0-7][0-9a-fA-F]|u\\{[0-9a-fA-F]{1,6}\\})") // inside a string
+
+	// heuristics to detect Rust
+	rustFn        = regexp.MustCompile("\\bfn\\s+\\w+")
+	rustLetMut    = regexp.MustCompile("\\blet\\s+mut\\b")
+	rustMacro     = regexp.MustCompile("\\b(println|print|vec|format|panic)!")
+	rustImplUse   = regexp.MustCompile("(?m)^\\s*(impl|use|pub)\\b")
+	rustDetection = &Detection{
+		Heuristics: []*regexp.Regexp{rustFn, rustLetMut, rustMacro, rustImplUse},
+		Keywords:   []*regexp.Regexp{rust1, rust2},
+	}
+
+	rustMainShortcut = &Shortcut{
+		regexp.MustCompile("\\*\\*main\\*\\*"),
+		"fn main() {\n\tprintln!(\"hello world\");\n}\n",
+	}
+)
+
+// Gets the Rust ranges for particular colors.
+func getRustRanges(comment, str, escape, lifetime, attribute *docs.Color) []*Range {
+	return []*Range{
+		{StartSymbol: "//", EndSymbol: "\n", Color: comment},
+		{StartSymbol: "/*", EndSymbol: "*/", Color: comment, Nested: true},
+		{StartPattern: rustRawString, EndSymbol: "\"$1", Color: str},
+		{StartSymbol: "\"", EndSymbol: "\"", Color: str, Escape: "\\", Inner: []*Range{{Pattern: rustEscape, Color: escape}}},
+		{Pattern: rustChar, Color: str},
+		{Pattern: rustLifetime, Color: lifetime},
+		{StartPattern: rustAttribute, EndSymbol: "]", Color: attribute},
+	}
+}
+
+var (
+	rustLang = &Language{
+		Name:      "Rust",
+		Format:    runner.FormatRust,
+		Run:       runner.RunRust,
+		Detect:    rustDetection,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes, rustMainShortcut},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getRustRanges(DarkThemeDarkGreen, DarkThemeLightRedOrange, DarkThemeStrawYellow, DarkThemeDarkBlue, DarkThemeStrawYellow),
+				[]Keyword{
+					{Regex: rust1, Color: DarkThemePink},
+					{Regex: rust2, Color: DarkThemeDarkBlue},
+					{Regex: rust3, Color: DarkThemeGreenCyan},
+					{Regex: rust4, Color: DarkThemeYellow},
+					{Regex: rust5, Color: DarkThemePaleGreen},
+				},
+			),
+			lightTheme: getLightTheme(
+				getRustRanges(LightThemeDarkGreen, LightThemeDarkRed, LightThemeBrightRed, Blue, LightThemeStrawYellow),
+				[]Keyword{
+					{Regex: rust1, Color: LightThemePink},
+					{Regex: rust2, Color: Blue},
+					{Regex: rust3, Color: LightThemeGreenCyan},
+					{Regex: rust4, Color: LightThemeStrawYellow},
+					{Regex: rust5, Color: LightThemePaleGreen},
+				},
+			),
+		},
+	}
+)
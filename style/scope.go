@@ -0,0 +1,87 @@
+package style
+
+import (
+	"strings"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// Note that the following scopes are taken from the VSCode themes found here:
+	// https://github.com/microsoft/vscode/tree/master/extensions/theme-defaults/themes
+
+	// colors of TextMate scopes for each theme
+	defaultScopes = map[string]map[string]*docs.Color{
+		darkTheme: {
+			"comment":                      DarkThemeDarkGreen,
+			"string":                       DarkThemeLightRedOrange,
+			"string.regexp":                DarkThemeLightRed,
+			"constant.character.escape":    DarkThemeStrawYellow,
+			"constant.numeric":             DarkThemePaleGreen,
+			"constant.language":            DarkThemeDarkBlue,
+			"keyword":                      DarkThemeDarkBlue,
+			"keyword.control":              DarkThemePink,
+			"keyword.operator":             DarkThemeForeground,
+			"storage":                      DarkThemeDarkBlue,
+			"entity.name.function":         DarkThemeYellow,
+			"support.function":             DarkThemeYellow,
+			"entity.name.type":             DarkThemeGreenCyan,
+			"entity.name.class":            DarkThemeGreenCyan,
+			"support.type":                 DarkThemeGreenCyan,
+			"support.class":                DarkThemeGreenCyan,
+			"entity.name.tag":              DarkThemeDarkBlue,
+			"entity.other.attribute-name":  DarkThemeLightBlue,
+			"variable":                     DarkThemeLightBlue,
+			"variable.language":            DarkThemeDarkBlue,
+			"markup.heading":               DarkThemeDarkBlue,
+			"invalid":                      DarkThemeLightRed,
+			"punctuation.definition.tag":   DarkThemeForeground,
+			"punctuation.definition.quote": DarkThemeLightBlue,
+		},
+		lightTheme: {
+			"comment":                      LightThemeDarkGreen,
+			"string":                       LightThemeDarkRed,
+			"string.regexp":                LightThemeDarkMaroon,
+			"constant.character.escape":    LightThemeRed,
+			"constant.numeric":             LightThemePaleGreen,
+			"constant.language":            Blue,
+			"keyword":                      Blue,
+			"keyword.control":              LightThemePink,
+			"keyword.operator":             Black,
+			"storage":                      Blue,
+			"entity.name.function":         LightThemeStrawYellow,
+			"support.function":             LightThemeStrawYellow,
+			"entity.name.type":             LightThemeGreenCyan,
+			"entity.name.class":            LightThemeGreenCyan,
+			"support.type":                 LightThemeGreenCyan,
+			"support.class":                LightThemeGreenCyan,
+			"entity.name.tag":              LightThemeMaroon,
+			"entity.other.attribute-name":  LightThemeRed,
+			"variable":                     LightThemeNavy,
+			"variable.language":            Blue,
+			"markup.heading":               LightThemeMaroon,
+			"invalid":                      LightThemeRed,
+			"punctuation.definition.tag":   LightThemeMaroon,
+			"punctuation.definition.quote": LightThemeCobalt,
+		},
+	}
+)
+
+// GetScopeColor gets the color of the innermost scope that has a color,
+// where the longest matching scope of the theme wins, or nil if none has a color.
+func (t *Theme) GetScopeColor(scopes []string) *docs.Color {
+	for i := len(scopes) - 1; i >= 0; i-- {
+		// try the scope, then its parents (e.g. `string.quoted` then `string`)
+		for s := scopes[i]; s != ""; {
+			if c, ok := t.Scopes[s]; ok {
+				return c
+			}
+			dot := strings.LastIndexByte(s, '.')
+			if dot < 0 {
+				break
+			}
+			s = s[:dot]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package style
+
+import (
+	"GDocs-Syntax-Highlighter/runner"
+	"regexp"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// Note that some of the following SQL regexes are taken/inspired from the VSCode language files found here:
+	// https://github.com/microsoft/vscode/tree/master/extensions/sql/syntaxes
+	sql1 = regexp.MustCompile("(?i)\\b(and|between|exists|false|in|is|like|not|null|or|true)\\b")
+	sql2 = regexp.MustCompile("(?i)\\b(add|all|alter|as|asc|begin|by|cascade|case|check|column|commit|constraint|create|cross|default|delete|desc|distinct|drop|else|end|except|foreign|from|full|group|having|if|index|inner|insert|intersect|into|join|key|left|limit|natural|offset|on|order|outer|over|partition|primary|recursive|references|replace|returning|right|rollback|select|set|table|temp|temporary|then|transaction|trigger|union|unique|update|using|values|view|when|where|window|with)\\b")
+	sql3 = regexp.MustCompile("(?i)\\b(bigint|blob|boolean|char|character|date|datetime|decimal|double|float|int|integer|numeric|precision|real|smallint|text|time|timestamp|varchar)\\b")
+	sql4 = regexp.MustCompile("(?i)\\b(abs|avg|cast|coalesce|count|length|lower|max|min|nullif|round|substr|substring|sum|trim|upper)\\b")
+	sql5 = regexp.MustCompile("\\b\\d+(\\.\\d+)?([eE][+-]?\\d+)?\\b")
+
+	// dialect specific keywords and functions
+	sqlite1   = regexp.MustCompile("(?i)\\b(abort|attach|autoincrement|conflict|detach|fail|glob|ignore|pragma|regexp|rowid|strict|vacuum|without)\\b")
+	sqlite2   = regexp.MustCompile("(?i)\\b(group_concat|ifnull|instr|julianday|printf|randomblob|strftime|total|typeof|unixepoch|zeroblob)\\b")
+	postgres1 = regexp.MustCompile("(?i)\\b(array|bigserial|bytea|conflict|declare|do|extension|function|ilike|interval|json|jsonb|language|lateral|materialized|nothing|only|perform|plpgsql|raise|schema|sequence|serial|similar|timestamptz|uuid)\\b")
+	postgres2 = regexp.MustCompile("(?i)\\b(array_agg|date_trunc|extract|generate_series|jsonb_build_object|now|string_agg|to_char)\\b")
+	mysql1    = regexp.MustCompile("(?i)\\b(auto_increment|databases|describe|duplicate|engine|enum|ignore|longtext|mediumint|mediumtext|regexp|rlike|show|straight_join|tinyint|tinytext|unsigned|use|zerofill)\\b")
+	mysql2    = regexp.MustCompile("(?i)\\b(concat|date_format|group_concat|ifnull|json_extract|last_insert_id|now)\\b")
+
+	// Postgres dollar-quoted strings (e.g. `$body$...$body$`) and escape strings (e.g. `E'\n'`)
+	postgresDollarString = regexp.MustCompile("^\\$([A-Za-z_]\\w*)?\\$")
+	postgresEscapeString = regexp.MustCompile("^[eE]'")
+	notWordFollows       = regexp.MustCompile("(^|\\W)$")
+
+	// heuristics to detect SQL
+	sqlSelect    = regexp.MustCompile("(?is)\\bselect\\b.+\\bfrom\\b")
+	sqlStatement = regexp.MustCompile("(?i)\\b(create\\s+table|insert\\s+into|update\\s+\\w+\\s+set|delete\\s+from)\\b")
+	sqlDetection = &Detection{
+		Heuristics: []*regexp.Regexp{sqlSelect, sqlStatement},
+		Keywords:   []*regexp.Regexp{sql1, sql2},
+	}
+)
+
+// Gets the SQL ranges for particular colors and dialect. Quoted identifiers
+// receive the identifier color, so that they are not highlighted as keywords.
+func getSQLRanges(comment, str, identifier *docs.Color, dialect string) []*Range {
+	ranges := []*Range{
+		{StartSymbol: "--", EndSymbol: "\n", Color: comment},
+		{StartSymbol: "/*", EndSymbol: "*/", Color: comment},
+	}
+	switch dialect {
+	case "postgres":
+		ranges = append(ranges,
+			&Range{StartPattern: postgresDollarString, EndSymbol: "$$${1}$$", Color: str},
+			&Range{StartPattern: postgresEscapeString, Follows: notWordFollows, EndSymbol: "'", Color: str, Escape: "\\"},
+			&Range{StartSymbol: "'", EndSymbol: "'", Color: str},
+			&Range{StartSymbol: "\"", EndSymbol: "\"", Color: identifier},
+		)
+	case "mysql":
+		ranges = append(ranges,
+			&Range{StartSymbol: "#", EndSymbol: "\n", Color: comment},
+			&Range{StartSymbol: "'", EndSymbol: "'", Color: str, Escape: "\\"},
+			&Range{StartSymbol: "\"", EndSymbol: "\"", Color: str, Escape: "\\"},
+			&Range{StartSymbol: "`", EndSymbol: "`", Color: identifier},
+		)
+	default:
+		ranges = append(ranges,
+			&Range{StartSymbol: "'", EndSymbol: "'", Color: str},
+			&Range{StartSymbol: "\"", EndSymbol: "\"", Color: identifier},
+			&Range{StartSymbol: "`", EndSymbol: "`", Color: identifier},
+			&Range{StartSymbol: "[", EndSymbol: "]", Color: identifier},
+		)
+	}
+	return ranges
+}
+
+// Gets a SQL language for a particular dialect and its keywords and functions.
+// Only SQLite can be run, on an embedded in-memory database.
+func getSQLLanguage(name, dialect string, keywords, functions *regexp.Regexp, run RunFunc, detect *Detection) *Language {
+	return &Language{
+		Name:      name,
+		Format:    runner.FormatSQL,
+		Run:       run,
+		Detect:    detect,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getSQLRanges(DarkThemeDarkGreen, DarkThemeLightRedOrange, DarkThemeForeground, dialect),
+				[]Keyword{
+					{Regex: sql1, Color: DarkThemePink},
+					{Regex: sql2, Color: DarkThemeDarkBlue},
+					{Regex: keywords, Color: DarkThemeDarkBlue},
+					{Regex: sql3, Color: DarkThemeGreenCyan},
+					{Regex: sql4, Color: DarkThemeYellow},
+					{Regex: functions, Color: DarkThemeYellow},
+					{Regex: sql5, Color: DarkThemePaleGreen},
+				},
+			),
+			lightTheme: getLightTheme(
+				getSQLRanges(LightThemeDarkGreen, LightThemeDarkRed, Black, dialect),
+				[]Keyword{
+					{Regex: sql1, Color: LightThemePink},
+					{Regex: sql2, Color: Blue},
+					{Regex: keywords, Color: Blue},
+					{Regex: sql3, Color: LightThemeGreenCyan},
+					{Regex: sql4, Color: LightThemeStrawYellow},
+					{Regex: functions, Color: LightThemeStrawYellow},
+					{Regex: sql5, Color: LightThemePaleGreen},
+				},
+			),
+		},
+	}
+}
+
+var (
+	sqlLang      = getSQLLanguage("SQL", "sqlite", sqlite1, sqlite2, runner.RunSQL, sqlDetection)
+	postgresLang = getSQLLanguage("PostgreSQL", "postgres", postgres1, postgres2, nil, nil)
+	mySQLLang    = getSQLLanguage("MySQL", "mysql", mysql1, mysql2, nil, nil)
+)
@@ -1,6 +1,8 @@
 package style
 
 import (
+	"GDocs-Syntax-Highlighter/textmate"
+	"regexp"
 	"strings"
 
 	"google.golang.org/api/docs/v1"
@@ -15,6 +17,10 @@ const (
 
 	// DefaultTheme is the default theme.
 	DefaultTheme = lightTheme
+
+	// DefaultBracketSetting is whether brackets are colored
+	// by their depth by default.
+	DefaultBracketSetting = false
 )
 
 var (
@@ -31,30 +37,120 @@ var (
 // Since underlines are used in directives, at the moment
 // they can not be removed from directive headers/footers.
 type Theme struct {
-	DocBackground       *docs.Color
-	CodeForeground      *docs.Color
-	CodeBackground      *docs.Color
-	CodeHighlight       *docs.Color
-	ConfigForeground    *docs.Color
-	ConfigBackground    *docs.Color
-	ConfigHighlight     *docs.Color
-	ConfigFont          string
-	ConfigFontSize      float64
-	ConfigItalics       bool
-	ConfigBold          bool
-	ConfigSmallCaps     bool
-	ConfigStrikethrough bool
-	Ranges              []*Range
-	Keywords            []Keyword
+	DocBackground        *docs.Color
+	CodeForeground       *docs.Color
+	CodeBackground       *docs.Color
+	CodeHighlight        *docs.Color
+	ConfigForeground     *docs.Color
+	ConfigBackground     *docs.Color
+	ConfigHighlight      *docs.Color
+	ConfigFont           string
+	ConfigFontSize       float64
+	ConfigItalics        bool
+	ConfigBold           bool
+	ConfigSmallCaps      bool
+	ConfigStrikethrough  bool
+	ErrorHighlight       *docs.Color
+	WarningHighlight     *docs.Color
+	BracketColors        []*docs.Color // colors of brackets by their depth, which repeat for deeper brackets
+	BracketError         *docs.Color   // color of brackets that are not matched
+	LineNumberForeground *docs.Color
+	LineHighlight        *docs.Color // background color of the lines that are highlighted
+	DiffAdded            *docs.Color // background color of the lines added by a diff
+	DiffRemoved          *docs.Color // background color of the lines removed by a diff
+	DiffHeader           *docs.Color // color of the hunk and file headers of a diff
+	Ranges               []*Range
+	Keywords             []Keyword
+	Grammar              *textmate.Grammar      // if set, the code is highlighted by the scopes of this grammar instead of the ranges
+	Scopes               map[string]*docs.Color // colors of scopes for a grammar, where a scope also matches its children (e.g. `string` matches `string.quoted`)
 }
 
 // Range represents an area of text that will receive the same color.
 // For instance, a comment.
-// For now, there is no notion of precedence.
+// Ranges are searched for from the start of the code, so a range hides any
+// range that starts inside of it. When several ranges start at the same rune,
+// the range with the highest precedence wins, then the first range.
 type Range struct {
+	StartSymbol    string
+	EndSymbol      string
+	Color          *docs.Color
+	Escape         string           // if set, the rune after the escape never ends the range (e.g. `\"`)
+	SingleLine     bool             // if set, the range also ends before the end of its line, unless the newline is escaped (e.g. an unterminated string)
+	Precedence     int              // ranges with a higher precedence win over ranges that start at the same rune
+	StartPattern   *regexp.Regexp   // if set, the range starts with a match of this `^` anchored regex, and its submatches can be used in the EndSymbol (e.g. `$1`)
+	Nested         bool             // if set, the start symbol nests, so the range ends at its matching end symbol (e.g. `/* /* */ */`)
+	Pattern        *regexp.Regexp   // if set, the range is a match of this `^` anchored regex instead of the symbols
+	Follows        *regexp.Regexp   // if set, the range only starts if the text before it on the same line matches
+	Precedes       *regexp.Regexp   // if set, the range only ends if the `^` anchored text after it matches (e.g. `:` after a JSON key)
+	Interpolations []*Interpolation // regions inside the range that are code
+	Inner          []*Range         // ranges inside the range that have their own color (e.g. `$x` in a shell string)
+	Embed          *Theme           // if set, the text between the symbols is code highlighted with this theme (e.g. CSS in HTML), and the start symbol may be empty
+	EmbedTheme     string           // if set, the text between the symbols is code in the language named by the first StartPattern submatch (e.g. a Markdown code fence), highlighted with its theme of this name
+}
+
+// Interpolation represents a region inside a Range that is code,
+// such as `${x}` in a JavaScript template literal.
+// The start and end symbols receive the color of the range,
+// and the code between them may contain other ranges.
+// The Open symbol nests, so `${ {a: 1} }` ends at the last `}`.
+type Interpolation struct {
 	StartSymbol string
 	EndSymbol   string
-	Color       *docs.Color
+	Open        string
+}
+
+// Gets the dark theme for particular ranges and keywords.
+func getDarkTheme(ranges []*Range, keywords []Keyword) *Theme {
+	return &Theme{
+		DocBackground:        DarkThemeBackground,
+		CodeForeground:       DarkThemeForeground,
+		CodeBackground:       DarkThemeBackground,
+		CodeHighlight:        Transparent,
+		ConfigForeground:     White,
+		ConfigBackground:     Black,
+		ConfigHighlight:      Transparent,
+		ConfigFont:           courierNew,
+		ConfigFontSize:       11,
+		ConfigItalics:        true,
+		ErrorHighlight:       DarkThemeErrorBackground,
+		WarningHighlight:     DarkThemeWarningBackground,
+		BracketColors:        []*docs.Color{DarkThemeBracketGold, DarkThemeBracketOrchid, DarkThemeBracketBlue},
+		BracketError:         UnexpectedBracketRed,
+		LineNumberForeground: DarkThemeLineNumber,
+		LineHighlight:        DarkThemeLineHighlight,
+		DiffAdded:            DarkThemeDiffAdded,
+		DiffRemoved:          DarkThemeDiffRemoved,
+		DiffHeader:           DarkThemeDarkBlue,
+		Ranges:               ranges,
+		Keywords:             keywords,
+	}
+}
+
+// Gets the light theme for particular ranges and keywords.
+func getLightTheme(ranges []*Range, keywords []Keyword) *Theme {
+	return &Theme{
+		DocBackground:        White,
+		CodeForeground:       Black,
+		CodeBackground:       White,
+		CodeHighlight:        Transparent,
+		ConfigForeground:     Black,
+		ConfigBackground:     LightGray,
+		ConfigHighlight:      Transparent,
+		ConfigFont:           courierNew,
+		ConfigFontSize:       11,
+		ConfigItalics:        true,
+		ErrorHighlight:       LightThemeErrorBackground,
+		WarningHighlight:     LightThemeWarningBackground,
+		BracketColors:        []*docs.Color{LightThemeBracketBlue, LightThemeBracketGreen, LightThemeBracketBrown},
+		BracketError:         UnexpectedBracketRed,
+		LineNumberForeground: LightThemeLineNumber,
+		LineHighlight:        LightThemeLineHighlight,
+		DiffAdded:            LightThemeDiffAdded,
+		DiffRemoved:          LightThemeDiffRemoved,
+		DiffHeader:           LightThemeNavy,
+		Ranges:               ranges,
+		Keywords:             keywords,
+	}
 }
 
 // GetTheme returns the theme and if it exists.
@@ -0,0 +1,74 @@
+package style
+
+import (
+	"GDocs-Syntax-Highlighter/runner"
+	"regexp"
+
+	"google.golang.org/api/docs/v1"
+)
+
+var (
+	// Note that some of the following YAML regexes are taken/inspired from the VSCode language files found here:
+	// https://github.com/microsoft/vscode/tree/master/extensions/yaml/syntaxes
+	yaml1 = regexp.MustCompile("\\b(true|True|TRUE|false|False|FALSE|null|Null|NULL)\\b|~")
+	yaml2 = regexp.MustCompile("-?\\b\\d+(\\.\\d+)?([eE][+-]?\\d+)?\\b")
+
+	// heuristics to detect YAML
+	yamlTopKey    = regexp.MustCompile("(?m)^[\\w.-]+:(\\s|$)")
+	yamlListItem  = regexp.MustCompile("(?m)^\\s*-\\s+[\\w\"']")
+	yamlDocument  = regexp.MustCompile("(?m)^---\\s*$")
+	yamlDetection = &Detection{
+		Heuristics: []*regexp.Regexp{yamlTopKey, yamlListItem, yamlDocument},
+	}
+
+	// A key is followed by a colon and a space (or the end of the line),
+	// and an unquoted key starts a line, a list item or a flow mapping entry.
+	yamlKey         = regexp.MustCompile("^[\\w.$/][\\w .$/-]*")
+	yamlKeyFollows  = regexp.MustCompile("(^\\s*(-\\s+)*|[{,]\\s*)$")
+	yamlKeyPrecedes = regexp.MustCompile("^\\s*:(\\s|$)")
+
+	// A comment, anchor, alias or tag starts a word.
+	yamlWordFollows = regexp.MustCompile("(^|\\s)$")
+	yamlAnchor      = regexp.MustCompile("^[&*][\\w-]+")
+	yamlTag         = regexp.MustCompile("^!!?[\\w/-]*")
+)
+
+// Gets the YAML ranges for particular colors.
+func getYAMLRanges(comment, key, str, anchor *docs.Color) []*Range {
+	return []*Range{
+		{StartSymbol: "#", EndSymbol: "\n", Color: comment, Follows: yamlWordFollows},
+		{Pattern: yamlKey, Color: key, Follows: yamlKeyFollows, Precedes: yamlKeyPrecedes},
+		{StartSymbol: "\"", EndSymbol: "\"", Color: key, Escape: "\\", Precedes: yamlKeyPrecedes},
+		{StartSymbol: "'", EndSymbol: "'", Color: key, Precedes: yamlKeyPrecedes},
+		{StartSymbol: "\"", EndSymbol: "\"", Color: str, Escape: "\\"},
+		{StartSymbol: "'", EndSymbol: "'", Color: str},
+		{Pattern: yamlAnchor, Color: anchor, Follows: yamlWordFollows},
+		{Pattern: yamlTag, Color: anchor, Follows: yamlWordFollows},
+	}
+}
+
+var (
+	yamlLang = &Language{
+		Name:      "YAML",
+		Format:    runner.FormatYAML,
+		Run:       runner.RunYAML,
+		Detect:    yamlDetection,
+		Shortcuts: []*Shortcut{doubleQuotes, singleQuotes},
+		Themes: map[string]*Theme{
+			darkTheme: getDarkTheme(
+				getYAMLRanges(DarkThemeDarkGreen, DarkThemeDarkBlue, DarkThemeLightRedOrange, DarkThemeGreenCyan),
+				[]Keyword{
+					{Regex: yaml1, Color: DarkThemeDarkBlue},
+					{Regex: yaml2, Color: DarkThemePaleGreen},
+				},
+			),
+			lightTheme: getLightTheme(
+				getYAMLRanges(LightThemeDarkGreen, LightThemeMaroon, Blue, LightThemeGreenCyan),
+				[]Keyword{
+					{Regex: yaml1, Color: Blue},
+					{Regex: yaml2, Color: LightThemePaleGreen},
+				},
+			),
+		},
+	}
+)
@@ -0,0 +1,232 @@
+package textmate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// grammars that can be included by their scope name (e.g. `source.css`)
+	grammars = make(map[string]*Grammar)
+
+	// A backreference in an end or while pattern to a capture of the begin pattern.
+	backreferenceRegex = regexp.MustCompile("\\\\(\\d)")
+)
+
+// Grammar is a TextMate grammar (e.g. a `.tmLanguage.json` file),
+// which tokenizes code into scopes such as `comment.line` or `keyword.control`.
+type Grammar struct {
+	ScopeName  string
+	root       *rule
+	repository map[string]*rule
+}
+
+// A rule of a grammar as declared in its JSON file.
+type rawRule struct {
+	Name                string              `json:"name"`
+	ContentName         string              `json:"contentName"`
+	Match               string              `json:"match"`
+	Begin               string              `json:"begin"`
+	End                 string              `json:"end"`
+	While               string              `json:"while"`
+	Captures            map[string]*rawRule `json:"captures"`
+	BeginCaptures       map[string]*rawRule `json:"beginCaptures"`
+	EndCaptures         map[string]*rawRule `json:"endCaptures"`
+	WhileCaptures       map[string]*rawRule `json:"whileCaptures"`
+	Patterns            []*rawRule          `json:"patterns"`
+	Include             string              `json:"include"`
+	Repository          map[string]*rawRule `json:"repository"`
+	ApplyEndPatternLast interface{}         `json:"applyEndPatternLast"` // a bool or a number
+	ScopeName           string              `json:"scopeName"`           // of the grammar, for the root rule
+}
+
+// A compiled rule, which either matches a pattern, begins and ends
+// (or continues while a pattern matches at the start of each line),
+// includes other rules, or only has patterns.
+type rule struct {
+	name        string
+	contentName string
+
+	match, begin *regex
+	end, while   string // patterns that can have backreferences to the begin captures
+	endRegex     *regex // compiled end or while pattern if it has no backreferences
+
+	captures, beginCaptures, endCaptures, whileCaptures map[int]*rule
+
+	patterns            []*rule
+	include             string // `$self` or another grammar's scope name, resolved when tokenizing
+	applyEndPatternLast bool
+
+	grammar *Grammar
+}
+
+// A compiler of the rules of a grammar, where each rule is compiled once
+// so that rules can include themselves.
+type compiler struct {
+	grammar *Grammar
+	rules   map[*rawRule]*rule
+}
+
+// Load loads a TextMate grammar from a JSON file.
+func Load(path string) (*Grammar, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Parse parses a TextMate grammar from JSON. Rules with patterns that
+// can not be converted to Go's regexp syntax are logged and never match.
+func Parse(b []byte) (*Grammar, error) {
+	var raw rawRule
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	if raw.ScopeName == "" {
+		return nil, fmt.Errorf("grammar has no scopeName")
+	}
+
+	g := &Grammar{ScopeName: raw.ScopeName, repository: make(map[string]*rule)}
+	c := &compiler{g, make(map[*rawRule]*rule)}
+	g.root = c.compile(&rawRule{Patterns: raw.Patterns, Repository: raw.Repository}, nil)
+	g.root.name = raw.ScopeName
+	for name, r := range raw.Repository {
+		g.repository[name] = c.compile(r, []map[string]*rawRule{raw.Repository})
+	}
+	return g, nil
+}
+
+// Register registers a grammar, so that other grammars can include it by its scope name.
+func Register(g *Grammar) {
+	grammars[g.ScopeName] = g
+}
+
+// Compiles a rule, where repositories are the repositories it is in,
+// from the grammar's to the innermost.
+func (c *compiler) compile(raw *rawRule, repositories []map[string]*rawRule) *rule {
+	if r, ok := c.rules[raw]; ok {
+		return r
+	}
+	r := &rule{
+		name:        raw.Name,
+		contentName: raw.ContentName,
+		end:         raw.End,
+		while:       raw.While,
+		grammar:     c.grammar,
+	}
+	c.rules[raw] = r
+	if raw.Repository != nil {
+		repositories = append(repositories[:len(repositories):len(repositories)], raw.Repository)
+	}
+
+	switch v := raw.ApplyEndPatternLast.(type) {
+	case bool:
+		r.applyEndPatternLast = v
+	case float64:
+		r.applyEndPatternLast = v != 0
+	}
+
+	var err error
+	if raw.Match != "" {
+		r.match, err = compileRegex(raw.Match)
+	} else if raw.Begin != "" {
+		if r.begin, err = compileRegex(raw.Begin); err == nil {
+			endPattern := raw.End
+			if raw.While != "" {
+				endPattern = raw.While
+			}
+			if !backreferenceRegex.MatchString(endPattern) {
+				r.endRegex, err = compileRegex(endPattern)
+			}
+		}
+	}
+	if err != nil {
+		log.Printf("Skipping rule `%s` of TextMate grammar `%s`: %v\n", raw.Name, c.grammar.ScopeName, err)
+		r.match, r.begin = nil, nil
+		return r
+	}
+
+	r.captures = c.compileCaptures(raw.Captures, repositories)
+	r.beginCaptures = c.compileCaptures(raw.BeginCaptures, repositories)
+	r.endCaptures = c.compileCaptures(raw.EndCaptures, repositories)
+	r.whileCaptures = c.compileCaptures(raw.WhileCaptures, repositories)
+	if raw.Begin != "" && raw.Captures != nil {
+		// captures apply to both the begin and the end
+		if raw.BeginCaptures == nil {
+			r.beginCaptures = r.captures
+		}
+		if raw.EndCaptures == nil {
+			r.endCaptures = r.captures
+		}
+	}
+
+	if raw.Include != "" {
+		if included := c.include(raw.Include, repositories); included != nil {
+			r.patterns = []*rule{included}
+		} else {
+			r.include = raw.Include
+		}
+	}
+	for _, p := range raw.Patterns {
+		r.patterns = append(r.patterns, c.compile(p, repositories))
+	}
+	return r
+}
+
+// Compiles the captures of a rule.
+func (c *compiler) compileCaptures(raw map[string]*rawRule, repositories []map[string]*rawRule) map[int]*rule {
+	if len(raw) == 0 {
+		return nil
+	}
+	captures := make(map[int]*rule)
+	for k, v := range raw {
+		if i, err := strconv.Atoi(k); err == nil {
+			captures[i] = c.compile(v, repositories)
+		}
+	}
+	return captures
+}
+
+// Gets an included rule of the grammar's repositories,
+// or nil if it is resolved when tokenizing.
+func (c *compiler) include(include string, repositories []map[string]*rawRule) *rule {
+	switch {
+	case strings.HasPrefix(include, "#"):
+		for i := len(repositories) - 1; i >= 0; i-- {
+			if raw, ok := repositories[i][include[1:]]; ok {
+				return c.compile(raw, repositories[:i+1])
+			}
+		}
+		log.Printf("Unknown include `%s` of TextMate grammar `%s`\n", include, c.grammar.ScopeName)
+		return &rule{grammar: c.grammar}
+	}
+	return nil
+}
+
+// Gets the rule included by a rule, which is the grammar itself,
+// or another grammar by its scope name and an optional repository name.
+// It returns nil if the other grammar is not registered.
+func (r *rule) getIncluded() *rule {
+	include := r.include
+	if include == "$self" || include == "$base" {
+		return r.grammar.root
+	}
+	scopeName, name := include, ""
+	if i := strings.IndexByte(include, '#'); i >= 0 {
+		scopeName, name = include[:i], include[i+1:]
+	}
+	g, ok := grammars[scopeName]
+	if !ok {
+		return nil
+	}
+	if name == "" {
+		return g.root
+	}
+	return g.repository[name]
+}
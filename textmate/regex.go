@@ -0,0 +1,422 @@
+package textmate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// A regex is an Oniguruma pattern converted to Go's regexp syntax.
+// Go does not support lookarounds, so lookarounds at the start or end
+// of a pattern are checked separately at the start or end of a match.
+// Other lookarounds, backreferences and `\G` after the start are unsupported.
+type regex struct {
+	source   string
+	re       *regexp.Regexp // the pattern without its lookarounds
+	afterRe  *regexp.Regexp // the pattern after any rune, to match with the rune before the search as context
+	anchored bool           // the pattern starts with `\G`, so it only matches where the search starts
+	leading  []*lookaround  // checked at the start of a match
+	trailing []*lookaround  // checked at the end of a match
+}
+
+// A lookaround checks the text before or after
+// a position of a match, without consuming it.
+type lookaround struct {
+	re       *regexp.Regexp // the lookaround's pattern, anchored to the position
+	afterRe  *regexp.Regexp // the pattern after any rune, for lookaheads
+	behind   bool
+	negative bool
+}
+
+var (
+	// Oniguruma flag groups such as `(?i)` or `(?x-i:`.
+	flagsRegex = regexp.MustCompile("^\\(\\?([imx]*)(?:-([imx]*))?([:)])")
+
+	// repetition quantifiers such as `{2}`, `{2,}` or `{2,3}`,
+	// without which a `{` or `}` is literal.
+	repeatRegex = regexp.MustCompile("^\\{\\d+(?:,\\d*)?\\}")
+
+	// Oniguruma escapes of a letter that Go does not support.
+	escapes = map[byte]string{
+		'h': "[0-9a-fA-F]",
+		'H': "[^0-9a-fA-F]",
+		'e': "\\x1B",
+		'Z': "$",
+	}
+	classEscapes = map[byte]string{
+		'h': "0-9a-fA-F",
+		'e': "\\x1B",
+	}
+)
+
+// Compiles an Oniguruma pattern.
+func compileRegex(pattern string) (*regex, error) {
+	r := &regex{source: pattern}
+	p := stripExtended(pattern)
+	if strings.HasPrefix(p, "\\G") {
+		r.anchored, p = true, p[2:]
+	}
+
+	r.leading, p, r.trailing = splitLookarounds(p)
+	var err error
+	if r.re, r.afterRe, err = compileGo(p, r.anchored, false); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Compiles a converted pattern and the pattern after any rune, anchored
+// to the start of the search if anchored, or to the end if behind.
+func compileGo(p string, anchored, behind bool) (re, afterRe *regexp.Regexp, err error) {
+	converted, err := convert(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefix, suffix := "", ""
+	if anchored {
+		prefix = "\\A"
+	}
+	if behind {
+		suffix = "\\z"
+	}
+	if re, err = regexp.Compile("(?m)" + prefix + "(?:" + converted + ")" + suffix); err != nil {
+		return nil, nil, err
+	}
+	if afterRe, err = regexp.Compile("(?m)" + prefix + "(?s:.)(?:" + converted + ")" + suffix); err != nil {
+		return nil, nil, err
+	}
+	return re, afterRe, nil
+}
+
+// Finds the leftmost match at or after pos in a line, returning its submatch
+// indices in the line, or nil if it does not match.
+func (r *regex) find(line string, pos int) []int {
+	for pos <= len(line) {
+		loc := findAfter(r.re, r.afterRe, line, pos)
+		if loc == nil {
+			return nil
+		}
+		if r.check(line, loc) {
+			return loc
+		}
+		if r.anchored || loc[0] == len(line) {
+			return nil
+		}
+		// try again after the start of the match
+		_, size := utf8.DecodeRuneInString(line[loc[0]:])
+		pos = loc[0] + size
+	}
+	return nil
+}
+
+// Checks the lookarounds of a match.
+func (r *regex) check(line string, loc []int) bool {
+	for _, l := range r.leading {
+		if !l.check(line, loc[0]) {
+			return false
+		}
+	}
+	for _, l := range r.trailing {
+		if !l.check(line, loc[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks a lookaround at a position of a line.
+func (l *lookaround) check(line string, pos int) bool {
+	var matched bool
+	if l.behind {
+		matched = l.re.MatchString(line[:pos])
+	} else {
+		matched = findAfter(l.re, l.afterRe, line, pos) != nil
+	}
+	return matched != l.negative
+}
+
+// Finds the leftmost match of re at or after pos in a line. After the start
+// of the line, afterRe is used from the previous rune, so that assertions
+// such as `\b` and `^` see the text before pos.
+func findAfter(re, afterRe *regexp.Regexp, line string, pos int) []int {
+	if pos == 0 {
+		return re.FindStringSubmatchIndex(line)
+	}
+	_, size := utf8.DecodeLastRuneInString(line[:pos])
+	start := pos - size
+	loc := afterRe.FindStringSubmatchIndex(line[start:])
+	if loc == nil {
+		return nil
+	}
+	// skip the rune matched before the pattern
+	_, size = utf8.DecodeRuneInString(line[start+loc[0]:])
+	loc[0] += size
+	for i := range loc {
+		if loc[i] >= 0 {
+			loc[i] += start
+		}
+	}
+	return loc
+}
+
+// Removes the whitespace and comments of a pattern in extended mode,
+// which starts with a flag group such as `(?x)`.
+func stripExtended(p string) string {
+	res := flagsRegex.FindStringSubmatch(p)
+	if res == nil || !strings.Contains(res[1], "x") || res[3] != ")" {
+		return p
+	}
+	var b strings.Builder
+	b.WriteString(strings.Replace(res[0], "x", "", 1))
+	class := 0
+	for i := len(res[0]); i < len(p); i++ {
+		switch c := p[i]; {
+		case c == '\\' && i+1 < len(p):
+			b.WriteString(p[i : i+2])
+			i++
+		case c == '[':
+			class++
+			b.WriteByte(c)
+			// a `]` first in a class is literal
+			if strings.HasPrefix(p[i+1:], "]") {
+				b.WriteByte(']')
+				i++
+			} else if strings.HasPrefix(p[i+1:], "^]") {
+				b.WriteString("^]")
+				i += 2
+			}
+		case c == ']' && class > 0:
+			class--
+			b.WriteByte(c)
+		case class == 0 && (c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+		case class == 0 && c == '#':
+			for i < len(p) && p[i] != '\n' {
+				i++
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.TrimPrefix(b.String(), "(?)")
+}
+
+// Splits the lookarounds at the start and end of a pattern without
+// top-level alternatives, returning the pattern between them.
+func splitLookarounds(p string) (leading []*lookaround, rest string, trailing []*lookaround) {
+	groups := getGroups(p)
+	if groups == nil {
+		return nil, p, nil
+	}
+	start, end := 0, len(p)
+	for _, g := range groups {
+		if g[0] != start {
+			break
+		}
+		l := newLookaround(p[g[0]:g[1]])
+		if l == nil || quantified(p, g[1]) {
+			break
+		}
+		leading = append(leading, l)
+		start = g[1]
+	}
+	for i := len(groups) - 1; i >= 0 && groups[i][0] >= start; i-- {
+		g := groups[i]
+		if g[1] != end {
+			break
+		}
+		l := newLookaround(p[g[0]:g[1]])
+		if l == nil {
+			break
+		}
+		trailing = append([]*lookaround{l}, trailing...)
+		end = g[0]
+	}
+	return leading, p[start:end], trailing
+}
+
+// Gets the lookaround of a group, or nil if it is not a lookaround
+// that can be compiled.
+func newLookaround(group string) *lookaround {
+	l := &lookaround{}
+	var inner string
+	switch {
+	case strings.HasPrefix(group, "(?="):
+		inner = group[3:]
+	case strings.HasPrefix(group, "(?!"):
+		l.negative, inner = true, group[3:]
+	case strings.HasPrefix(group, "(?<="):
+		l.behind, inner = true, group[4:]
+	case strings.HasPrefix(group, "(?<!"):
+		l.behind, l.negative, inner = true, true, group[4:]
+	default:
+		return nil
+	}
+	inner = inner[:len(inner)-1]
+
+	var err error
+	if l.re, l.afterRe, err = compileGo(inner, !l.behind, l.behind); err != nil {
+		// an invalid lookaround is left in the pattern, where it fails to compile
+		return nil
+	}
+	return l
+}
+
+// Gets the indices of the top-level groups of a pattern,
+// or nil if the pattern has top-level alternatives.
+func getGroups(p string) (groups [][2]int) {
+	depth, class, start := 0, 0, 0
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case c == '\\':
+			i++
+		case class > 0:
+			if c == '[' {
+				class++
+			} else if c == ']' {
+				class--
+			}
+		case c == '[':
+			class++
+			// a `]` first in a class is literal
+			if strings.HasPrefix(p[i+1:], "]") {
+				i++
+			} else if strings.HasPrefix(p[i+1:], "^]") {
+				i += 2
+			}
+		case c == '(':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				groups = append(groups, [2]int{start, i + 1})
+			}
+		case c == '|' && depth == 0:
+			return nil
+		}
+	}
+	return
+}
+
+// Checks if a quantifier follows an index of a pattern.
+func quantified(p string, i int) bool {
+	return i < len(p) && (strings.ContainsAny(p[i:i+1], "*+?") || repeatRegex.MatchString(p[i:]))
+}
+
+// Converts an Oniguruma pattern to Go's regexp syntax,
+// returning an error for unsupported features.
+func convert(p string) (string, error) {
+	var b strings.Builder
+	class := 0
+	quantifier := false // whether the last token is a quantifier, which a `+` makes possessive
+	for i := 0; i < len(p); i++ {
+		c, afterQuantifier := p[i], quantifier
+		quantifier = false
+		switch {
+		case c == '\\' && i+1 < len(p):
+			e := p[i+1]
+			i++
+			switch {
+			case e >= '1' && e <= '9' && class == 0:
+				return "", fmt.Errorf("unsupported backreference `\\%c` in `%s`", e, p)
+			case e == 'G' || e == 'K' || e == 'k' || e == 'g':
+				return "", fmt.Errorf("unsupported escape `\\%c` in `%s`", e, p)
+			case e == 'u' && i+5 <= len(p) && isHex(p[i+1:i+5]):
+				b.WriteString("\\x{" + p[i+1:i+5] + "}")
+				i += 4
+			case class > 0 && classEscapes[e] != "":
+				b.WriteString(classEscapes[e])
+			case class == 0 && escapes[e] != "":
+				b.WriteString(escapes[e])
+			default:
+				b.WriteByte(c)
+				b.WriteByte(e)
+			}
+		case class > 0:
+			if c == '[' && !strings.HasPrefix(p[i:], "[:") {
+				return "", fmt.Errorf("unsupported nested class in `%s`", p)
+			}
+			if strings.HasPrefix(p[i:], "[:") {
+				end := strings.Index(p[i:], ":]")
+				if end < 0 {
+					return "", fmt.Errorf("unterminated POSIX class in `%s`", p)
+				}
+				b.WriteString(p[i : i+end+2])
+				i += end + 1
+				continue
+			}
+			if c == ']' {
+				class--
+			}
+			b.WriteByte(c)
+		case c == '[':
+			class++
+			b.WriteByte(c)
+			// a `]` first in a class is literal
+			if strings.HasPrefix(p[i+1:], "]") {
+				b.WriteString("\\]")
+				i++
+			} else if strings.HasPrefix(p[i+1:], "^]") {
+				b.WriteString("^\\]")
+				i += 2
+			}
+		case strings.HasPrefix(p[i:], "(?<=") || strings.HasPrefix(p[i:], "(?<!") ||
+			strings.HasPrefix(p[i:], "(?=") || strings.HasPrefix(p[i:], "(?!"):
+			return "", fmt.Errorf("unsupported lookaround in `%s`", p)
+		case strings.HasPrefix(p[i:], "(?<"):
+			b.WriteString("(?P<")
+			i += 2
+		case strings.HasPrefix(p[i:], "(?>"):
+			b.WriteString("(?:")
+			i += 2
+		case strings.HasPrefix(p[i:], "(?#"):
+			end := strings.IndexByte(p[i:], ')')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated comment in `%s`", p)
+			}
+			i += end
+		case c == '(':
+			if res := flagsRegex.FindStringSubmatch(p[i:]); res != nil {
+				// Oniguruma's `m` is Go's `s`, and extended mode is only supported at the start
+				on := strings.Replace(strings.Replace(res[1], "x", "", 1), "m", "s", 1)
+				off := strings.Replace(strings.Replace(res[2], "x", "", 1), "m", "s", 1)
+				flags := on
+				if off != "" {
+					flags += "-" + off
+				}
+				if flags == "" && res[3] == ")" {
+					i += len(res[0]) - 1
+					continue
+				}
+				b.WriteString("(?" + flags + res[3])
+				i += len(res[0]) - 1
+				continue
+			}
+			b.WriteByte(c)
+		case c == '+' && afterQuantifier:
+			// possessive quantifiers are greedy
+		case c == '{' && repeatRegex.MatchString(p[i:]):
+			repeat := repeatRegex.FindString(p[i:])
+			b.WriteString(repeat)
+			i += len(repeat) - 1
+			quantifier = true
+		default:
+			quantifier = strings.IndexByte("*+?", c) >= 0
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// Checks if a string is hex digits.
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", rune(s[i])) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,176 @@
+package textmate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    string
+	}{
+		// possessive quantifiers
+		{"possessive plus", "a++", "a+"},
+		{"possessive star", "a*+b", "a*b"},
+		{"possessive question", "a?+", "a?"},
+		{"possessive repeat", "a{2,3}+", "a{2,3}"},
+		{"escaped plus quantified", "\\++", "\\++"},
+		{"escaped plus possessive", "\\+++", "\\++"},
+		{"escaped backslash possessive", "\\\\++", "\\\\+"},
+		{"escaped brace plus", "\\}+", "\\}+"},
+		{"literal brace plus", "a}+", "a}+"},
+		{"literal brace repeat", "a{x}+", "a{x}+"},
+		{"open repeat possessive", "a{2,}+", "a{2,}"},
+		{"plus in class", "[+]+", "[+]+"},
+		// a `]` first in a class is literal
+		{"bracket first", "[]a]", "[\\]a]"},
+		{"negated bracket first", "[^]a]", "[^\\]a]"},
+		{"POSIX class", "[[:alpha:]_]", "[[:alpha:]_]"},
+		// escapes
+		{"hex escape", "\\h+", "[0-9a-fA-F]+"},
+		{"hex class escape", "[\\h_]", "[0-9a-fA-F_]"},
+		{"unicode escape", "\\u00e9a", "\\x{00e9}a"},
+		{"unicode escape at the end", "a\\u00e9", "a\\x{00e9}"},
+		{"short unicode escape", "a\\u00e", "a\\u00e"},
+		{"invalid unicode escape", "\\u00zz", "\\u00zz"},
+		// groups
+		{"named group", "(?<name>a)", "(?P<name>a)"},
+		{"atomic group", "(?>a)", "(?:a)"},
+		{"comment", "a(?# note )b", "ab"},
+		{"multiline flag", "(?m:a.)", "(?s:a.)"},
+		{"extended flag", "(?ix:a)", "(?i:a)"},
+	}
+	for _, tt := range tests {
+		got, err := convert(tt.pattern)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: convert(%q) = %q, %v, want %q", tt.name, tt.pattern, got, err, tt.want)
+		}
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"(a)\\1", "backreference"},
+		{"\\Ga", "escape"},
+		{"a(?=b)c", "lookaround"},
+		{"[a[b]]", "nested class"},
+		{"[[:alpha]", "POSIX class"},
+		{"a(?# note", "comment"},
+	}
+	for _, tt := range tests {
+		if _, err := convert(tt.pattern); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("convert(%q) error = %v, want it to contain %q", tt.pattern, err, tt.want)
+		}
+	}
+}
+
+func TestStripExtended(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"(?x) a b # comment\n c", "abc"},
+		{"(?x)\ta\\ b", "a\\ b"},
+		// whitespace and `#` in a class are kept
+		{"(?x)[ #] a", "[ #]a"},
+		{"(?x)[]#] a", "[]#]a"},
+		{"(?x)[^]#] a", "[^]#]a"},
+		{"(?ix) a", "(?i)a"},
+		// not extended
+		{"a b # c", "a b # c"},
+		{"(?i) a", "(?i) a"},
+		{"(?x:a) b", "(?x:a) b"},
+	}
+	for _, tt := range tests {
+		if got := stripExtended(tt.pattern); got != tt.want {
+			t.Errorf("stripExtended(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestSplitLookarounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		leading  []string // `=`, `!`, `<=` or `<!` for each lookaround
+		rest     string
+		trailing []string
+	}{
+		{"none", "ab", nil, "ab", nil},
+		{"leading", "(?<=a)(?!b)c", []string{"<=", "!"}, "c", nil},
+		{"trailing", "a(?=b)(?<!c)", nil, "a", []string{"=", "<!"}},
+		{"both", "(?<!\\w)a(?!\\w)", []string{"<!"}, "a", []string{"!"}},
+		{"only lookarounds", "(?=a)(?=b)", []string{"=", "="}, "", nil},
+		{"quantified leading", "(?=a)?b", nil, "(?=a)?b", nil},
+		{"quantified trailing", "a(?=b)*", nil, "a(?=b)*", nil},
+		{"after quantified leading", "(?=a)(?!b)+c", []string{"="}, "(?!b)+c", nil},
+		{"group", "(a)(?=b)", nil, "(a)", []string{"="}},
+		{"alternatives", "(?=a)b|c", nil, "(?=a)b|c", nil},
+		{"alternatives in a group", "(?=a|b)c", []string{"="}, "c", nil},
+		{"paren in a class", "(?=[)])a", []string{"="}, "a", nil},
+		{"bracket first in a class", "(?=[])])a", []string{"="}, "a", nil},
+	}
+	kinds := func(ls []*lookaround) (res []string) {
+		for _, l := range ls {
+			kind := "="
+			if l.negative {
+				kind = "!"
+			}
+			if l.behind {
+				kind = "<" + kind
+			}
+			res = append(res, kind)
+		}
+		return
+	}
+	for _, tt := range tests {
+		leading, rest, trailing := splitLookarounds(tt.pattern)
+		if !reflect.DeepEqual(kinds(leading), tt.leading) || rest != tt.rest || !reflect.DeepEqual(kinds(trailing), tt.trailing) {
+			t.Errorf("%s: splitLookarounds(%q) = %v, %q, %v, want %v, %q, %v",
+				tt.name, tt.pattern, kinds(leading), rest, kinds(trailing), tt.leading, tt.rest, tt.trailing)
+		}
+	}
+}
+
+func TestRegexFind(t *testing.T) {
+	tests := []struct {
+		pattern string
+		line    string
+		pos     int
+		want    []int // the indices of the whole match
+	}{
+		{"\\bb", "ab b", 0, []int{3, 4}},
+		// assertions see the text before the position
+		{"\\bb", "ab", 1, nil},
+		{"^a", "ba", 1, nil},
+		{"(?<=a)b", "bab", 0, []int{2, 3}},
+		{"(?<!a)b", "abb", 0, []int{2, 3}},
+		{"a(?=b)", "acab", 0, []int{2, 3}},
+		{"a(?!b)", "abac", 0, []int{2, 3}},
+		{"(?<=\\.)\\w+(?=\\()", "x.f(", 0, []int{2, 3}},
+		// an anchored pattern only matches where the search starts
+		{"\\Gb", "ab", 1, []int{1, 2}},
+		{"\\Gb", "aab", 1, nil},
+		{"é(?=a)", "ééa", 0, []int{2, 4}},
+	}
+	for _, tt := range tests {
+		r, err := compileRegex(tt.pattern)
+		if err != nil {
+			t.Errorf("compileRegex(%q) error = %v", tt.pattern, err)
+			continue
+		}
+		got := r.find(tt.line, tt.pos)
+		if got != nil {
+			got = got[:2]
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q.find(%q, %d) = %v, want %v", tt.pattern, tt.line, tt.pos, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package textmate
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// A reference to a capture in a scope name (e.g. `entity.name.tag.$1` or `${1:/downcase}`).
+	captureReferenceRegex = regexp.MustCompile("\\$(\\d+)|\\$\\{(\\d+):/(downcase|upcase)\\}")
+)
+
+// Token is a part of code with the same scopes.
+type Token struct {
+	Start  int      // utf8 index in the code
+	End    int      // utf8 index in the code
+	Scopes []string // from the grammar's scope name to the innermost scope
+}
+
+// A rule that began and has not ended, with the scopes of the code inside it.
+type frame struct {
+	rule          *rule
+	end           *regex // nil for a while rule
+	while         *regex // nil for an end rule
+	scopes        []string
+	contentScopes []string
+	line, pos     int  // where the content began
+	empty         bool // the begin match was empty
+}
+
+// A cached match of a regex in the current line, searched at pos.
+type cachedMatch struct {
+	pos int
+	loc []int
+}
+
+// A tokenizer of code, which tokenizes it line by line.
+type tokenizer struct {
+	tokens   []Token
+	patterns map[*rule][]*rule
+	cache    map[*regex]cachedMatch
+}
+
+// Tokenize tokenizes code into tokens with the scopes of the grammar,
+// which cover the whole code in order.
+func (g *Grammar) Tokenize(code string) []Token {
+	t := &tokenizer{patterns: make(map[*rule][]*rule)}
+	scopes := []string{g.ScopeName}
+	stack := []*frame{{rule: g.root, scopes: scopes, contentScopes: scopes}}
+	for line, offset := 0, 0; offset < len(code); line++ {
+		end := len(code)
+		if i := strings.IndexByte(code[offset:], '\n'); i >= 0 {
+			end = offset + i + 1
+		}
+		stack = t.tokenizeLine(code[offset:end], offset, line, stack)
+		offset = end
+	}
+	return t.tokens
+}
+
+// Tokenizes a line at an offset in the code,
+// returning the stack of rules that have not ended.
+func (t *tokenizer) tokenizeLine(line string, offset, lineNum int, stack []*frame) []*frame {
+	t.cache = make(map[*regex]cachedMatch)
+	pos := 0
+
+	// end the while rules that do not continue at the start of the line
+	for i := 1; i < len(stack); i++ {
+		f := stack[i]
+		if f.while == nil {
+			continue
+		}
+		loc := f.while.find(line, pos)
+		if loc == nil || loc[0] != pos {
+			stack = stack[:i]
+			break
+		}
+		t.emitCaptures(line, offset, loc, f.rule.whileCaptures, f.scopes)
+		pos = loc[1]
+	}
+
+	for pos < len(line) {
+		top := stack[len(stack)-1]
+		loc, matched := t.match(line, pos, top)
+		if loc == nil {
+			t.emit(offset+pos, offset+len(line), top.contentScopes)
+			break
+		}
+		t.emit(offset+pos, offset+loc[0], top.contentScopes)
+
+		advance := loc[1] == loc[0]
+		switch {
+		case matched == nil:
+			// end of the top rule
+			t.emitCaptures(line, offset, loc, top.rule.endCaptures, top.scopes)
+			stack = stack[:len(stack)-1]
+			// an empty rule that began and ended at the same position makes no progress
+			advance = advance && top.empty && top.line == lineNum && top.pos == loc[0]
+		case matched.match != nil:
+			scopes := pushScopes(top.contentScopes, substituteCaptures(matched.name, line, loc))
+			t.emitCaptures(line, offset, loc, matched.captures, scopes)
+		default:
+			scopes := pushScopes(top.contentScopes, substituteCaptures(matched.name, line, loc))
+			t.emitCaptures(line, offset, loc, matched.beginCaptures, scopes)
+			if advance && top.rule == matched && top.line == lineNum && top.pos == loc[0] {
+				// the rule began again without progress
+				break
+			}
+			if f := newFrame(matched, line, loc, scopes); f != nil {
+				f.line, f.pos, f.empty = lineNum, loc[1], advance
+				stack = append(stack, f)
+				advance = false
+			}
+		}
+
+		pos = loc[1]
+		if advance {
+			// skip a rune to not match the same empty match again
+			_, size := utf8.DecodeRuneInString(line[pos:])
+			t.emit(offset+pos, offset+pos+size, stack[len(stack)-1].contentScopes)
+			pos += size
+		}
+	}
+	return stack
+}
+
+// Gets the frame of a rule that began with a match,
+// or nil if its end or while pattern does not compile.
+func newFrame(r *rule, line string, loc []int, scopes []string) *frame {
+	f := &frame{rule: r, scopes: scopes, contentScopes: pushScopes(scopes, substituteCaptures(r.contentName, line, loc))}
+	end := r.endRegex
+	if end == nil {
+		// replace the backreferences to the begin captures
+		pattern := r.end
+		if r.while != "" {
+			pattern = r.while
+		}
+		pattern = backreferenceRegex.ReplaceAllStringFunc(pattern, func(ref string) string {
+			i, _ := strconv.Atoi(ref[1:])
+			if 2*i+1 >= len(loc) || loc[2*i] < 0 {
+				return ""
+			}
+			return regexp.QuoteMeta(line[loc[2*i]:loc[2*i+1]])
+		})
+		var err error
+		if end, err = compileRegex(pattern); err != nil {
+			log.Printf("Skipping rule `%s` of TextMate grammar `%s`: %v\n", r.name, r.grammar.ScopeName, err)
+			return nil
+		}
+	}
+	if r.while != "" {
+		f.while = end
+	} else {
+		f.end = end
+	}
+	return f
+}
+
+// Finds the leftmost match of the end of the top rule or its patterns at or
+// after pos, where the earlier pattern of matches at the same position wins.
+// It returns the match and its rule, which is nil for the end of the top rule.
+func (t *tokenizer) match(line string, pos int, top *frame) (best []int, matched *rule) {
+	var endLoc []int
+	if top.end != nil {
+		endLoc = t.find(top.end, line, pos)
+		if !top.rule.applyEndPatternLast {
+			best = endLoc
+		}
+	}
+	for _, p := range t.getPatterns(top.rule) {
+		if best != nil && best[0] == pos {
+			break
+		}
+		r := p.match
+		if r == nil {
+			r = p.begin
+		}
+		if loc := t.find(r, line, pos); loc != nil && (best == nil || loc[0] < best[0]) {
+			best, matched = loc, p
+		}
+	}
+	if top.rule.applyEndPatternLast && endLoc != nil && (best == nil || endLoc[0] < best[0]) {
+		best, matched = endLoc, nil
+	}
+	return
+}
+
+// Finds the leftmost match of a regex at or after pos, reusing the match
+// of an earlier search in the line if it is at or after pos.
+func (t *tokenizer) find(r *regex, line string, pos int) []int {
+	if c, ok := t.cache[r]; ok && !r.anchored && c.pos <= pos && (c.loc == nil || c.loc[0] >= pos) {
+		return c.loc
+	}
+	loc := r.find(line, pos)
+	t.cache[r] = cachedMatch{pos, loc}
+	return loc
+}
+
+// Gets the rules that can match inside a rule,
+// resolving included rules and rules that only have patterns.
+func (t *tokenizer) getPatterns(r *rule) []*rule {
+	if patterns, ok := t.patterns[r]; ok {
+		return patterns
+	}
+	var patterns []*rule
+	visited := make(map[*rule]bool)
+	var add func(*rule)
+	add = func(p *rule) {
+		if visited[p] {
+			return
+		}
+		visited[p] = true
+		if p.match != nil || p.begin != nil {
+			patterns = append(patterns, p)
+			return
+		}
+		if p.include != "" {
+			if included := p.getIncluded(); included != nil {
+				add(included)
+			}
+		}
+		for _, inner := range p.patterns {
+			add(inner)
+		}
+	}
+	for _, p := range r.patterns {
+		add(p)
+	}
+	t.patterns[r] = patterns
+	return patterns
+}
+
+// Emits the tokens of a match, where captures add their scopes
+// to the parts of the match they capture (captures can be nested).
+// A capture with patterns tokenizes its part with them.
+func (t *tokenizer) emitCaptures(line string, offset int, loc []int, captures map[int]*rule, scopes []string) {
+	if len(captures) == 0 {
+		t.emit(offset+loc[0], offset+loc[1], scopes)
+		return
+	}
+
+	type span struct {
+		end    int
+		scopes []string
+	}
+	stack := []span{{loc[1], scopes}}
+	pos := loc[0]
+	for i := 0; 2*i+1 < len(loc); i++ {
+		c, ok := captures[i]
+		start, end := loc[2*i], loc[2*i+1]
+		if !ok || start < pos || start >= end {
+			continue
+		}
+		// end the spans of the captures before this one
+		for len(stack) > 1 && stack[len(stack)-1].end <= start {
+			t.emit(offset+pos, offset+stack[len(stack)-1].end, stack[len(stack)-1].scopes)
+			pos = stack[len(stack)-1].end
+			stack = stack[:len(stack)-1]
+		}
+		top := stack[len(stack)-1]
+		if end > top.end {
+			end = top.end
+		}
+		t.emit(offset+pos, offset+start, top.scopes)
+		pos = start
+
+		captureScopes := pushScopes(top.scopes, substituteCaptures(c.name, line, loc))
+		if len(c.patterns) > 0 || c.include != "" {
+			t.tokenizeCapture(line[start:end], offset+start, c, captureScopes)
+			pos = end
+			continue
+		}
+		stack = append(stack, span{end, captureScopes})
+	}
+	for len(stack) > 0 {
+		t.emit(offset+pos, offset+stack[len(stack)-1].end, stack[len(stack)-1].scopes)
+		pos = stack[len(stack)-1].end
+		stack = stack[:len(stack)-1]
+	}
+}
+
+// Tokenizes the part of a line captured by a capture with patterns.
+func (t *tokenizer) tokenizeCapture(text string, offset int, c *rule, scopes []string) {
+	cache := t.cache
+	stack := []*frame{{rule: c, scopes: scopes, contentScopes: scopes}}
+	t.tokenizeLine(text, offset, -1, stack)
+	t.cache = cache
+}
+
+// Emits a token if it is not empty.
+func (t *tokenizer) emit(start, end int, scopes []string) {
+	if end > start {
+		t.tokens = append(t.tokens, Token{start, end, scopes})
+	}
+}
+
+// Gets new scopes with the space-separated scope names added.
+func pushScopes(scopes []string, names string) []string {
+	fields := strings.Fields(names)
+	if len(fields) == 0 {
+		return scopes
+	}
+	return append(append(make([]string, 0, len(scopes)+len(fields)), scopes...), fields...)
+}
+
+// Replaces the references to captures in a scope name with the captured text.
+func substituteCaptures(name, line string, loc []int) string {
+	if !strings.Contains(name, "$") {
+		return name
+	}
+	return captureReferenceRegex.ReplaceAllStringFunc(name, func(ref string) string {
+		res := captureReferenceRegex.FindStringSubmatch(ref)
+		i, _ := strconv.Atoi(res[1] + res[2])
+		if 2*i+1 >= len(loc) || loc[2*i] < 0 {
+			return ""
+		}
+		captured := line[loc[2*i]:loc[2*i+1]]
+		switch res[3] {
+		case "downcase":
+			captured = strings.ToLower(captured)
+		case "upcase":
+			captured = strings.ToUpper(captured)
+		}
+		return captured
+	})
+}
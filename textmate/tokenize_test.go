@@ -0,0 +1,130 @@
+package textmate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testGrammar = `{
+	"scopeName": "source.test",
+	"patterns": [
+		{"include": "#comment"},
+		{"include": "#string"},
+		{"include": "#block"},
+		{"include": "#heredoc"},
+		{"include": "#quote"},
+		{"match": "\\b(func)\\s+(\\w+)", "captures": {"1": {"name": "keyword.$1"}, "2": {"name": "entity.name.function"}}},
+		{"include": "source.other"}
+	],
+	"repository": {
+		"comment": {"match": "//.*$", "name": "comment.line"},
+		"string": {
+			"begin": "\"", "end": "\"", "name": "string.quoted",
+			"patterns": [{"match": "\\\\.", "name": "constant.character.escape"}]
+		},
+		"block": {
+			"begin": "\\{", "end": "\\}", "contentName": "meta.block",
+			"beginCaptures": {"0": {"name": "punctuation.begin"}},
+			"endCaptures": {"0": {"name": "punctuation.end"}},
+			"patterns": [{"include": "$self"}]
+		},
+		"heredoc": {
+			"begin": "<<(\\w+)$", "end": "^\\1$", "name": "string.heredoc",
+			"beginCaptures": {"1": {"name": "entity.name.tag.${1:/downcase}"}}
+		},
+		"quote": {"begin": "^> ", "while": "^> ", "name": "markup.quote"}
+	}
+}`
+
+// Gets the text and the scopes (after the grammar's) of tokens, such as
+// `"a":string.quoted`, where adjacent tokens with the same scopes are merged.
+func formatTokens(code string, tokens []Token) (res []string) {
+	for i := 0; i < len(tokens); {
+		start, scopes := tokens[i].Start, strings.Join(tokens[i].Scopes[1:], " ")
+		for i++; i < len(tokens) && strings.Join(tokens[i].Scopes[1:], " ") == scopes; i++ {
+		}
+		res = append(res, fmt.Sprintf("%q:%s", code[start:tokens[i-1].End], scopes))
+	}
+	return
+}
+
+func TestTokenize(t *testing.T) {
+	g, err := Parse([]byte(testGrammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{"match captures", "func main", []string{
+			`"func":keyword.func`, `" ":`, `"main":entity.name.function`,
+		}},
+		{"begin end", `a "b\"c" // d`, []string{
+			`"a ":`, `"\"b":string.quoted`, `"\\\"":string.quoted constant.character.escape`,
+			`"c\"":string.quoted`, `" ":`, `"// d":comment.line`,
+		}},
+		{"self include", "{ { \"x\" } }", []string{
+			`"{":punctuation.begin`, `" ":meta.block`, `"{":meta.block punctuation.begin`, `" ":meta.block meta.block`,
+			`"\"x\"":meta.block meta.block string.quoted`, `" ":meta.block meta.block`, `"}":meta.block punctuation.end`,
+			`" ":meta.block`, `"}":punctuation.end`,
+		}},
+		{"end backreference", "<<EOF\nEND\nEOF\nx", []string{
+			`"<<":string.heredoc`, `"EOF":string.heredoc entity.name.tag.eof`, `"\nEND\nEOF":string.heredoc`, `"\nx":`,
+		}},
+		{"while", "> a\n> b\nc\n", []string{
+			`"> a\n> b\n":markup.quote`, `"c\n":`,
+		}},
+		{"unterminated begin", "\"a\nb", []string{
+			`"\"a\nb":string.quoted`,
+		}},
+	}
+	for _, tt := range tests {
+		tokens := g.Tokenize(tt.code)
+		if got := formatTokens(tt.code, tokens); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Tokenize(%q) =\n%s\nwant\n%s", tt.name, tt.code, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+func TestTokenizeOtherGrammar(t *testing.T) {
+	g, err := Parse([]byte(testGrammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// another grammar is included once it is registered
+	code := "x @y"
+	if got := formatTokens(code, g.Tokenize(code)); !reflect.DeepEqual(got, []string{`"x @y":`}) {
+		t.Errorf("Tokenize(%q) = %v before registering the other grammar", code, got)
+	}
+	other, err := Parse([]byte(`{"scopeName": "source.other", "patterns": [{"match": "@\\w+", "name": "variable.other"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Register(other)
+	defer delete(grammars, other.ScopeName)
+	want := []string{`"x ":`, `"@y":variable.other`}
+	if got := formatTokens(code, g.Tokenize(code)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize(%q) = %v, want %v", code, got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse([]byte(`{"patterns": []}`)); err == nil {
+		t.Error("Parse() of a grammar without a scopeName succeeded, want an error")
+	}
+	if _, err := Parse([]byte(`not json`)); err == nil {
+		t.Error("Parse() of invalid JSON succeeded, want an error")
+	}
+	// a rule that can not be converted never matches
+	g, err := Parse([]byte(`{"scopeName": "source.a", "patterns": [{"match": "(a)\\1", "name": "bad"}, {"match": "a", "name": "good"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := formatTokens("aa", g.Tokenize("aa")); !reflect.DeepEqual(got, []string{`"aa":good`}) {
+		t.Errorf("Tokenize() = %v, want the good rule", got)
+	}
+}